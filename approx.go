@@ -0,0 +1,214 @@
+package goutil
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ApproxEqual reports whether a and b are deeply equal, like reflect.DeepEqual, except that floating-point
+// and complex numbers only need to be close: |x-y| <= max(tolerance * max(|x|, |y|), tolerance), see Float64IsClose.
+// The real and imaginary parts of complex numbers are compared separately.
+//
+// ApproxEqual walks pointers, interfaces, arrays, slices, maps and structs, including their unexported fields,
+// and detects cycles through pointers, maps and slices like reflect.DeepEqual.
+// A nil slice or map is equal to an empty one. Functions are only equal if both are nil.
+// If a and b differ, diff describes the first difference found, such as `Items[2].Price: 1.5 != 1.6`.
+// Map keys are visited in the order of their formatted values, so the diff is stable.
+func ApproxEqual(a, b interface{}, tolerance float64) (equal bool, diff string) {
+	c := approxComparer{tolerance: tolerance, visited: make(map[visit]bool)}
+	diff = c.compare("", reflect.ValueOf(a), reflect.ValueOf(b))
+
+	return diff == "", diff
+}
+
+type approxComparer struct {
+	tolerance float64
+	visited   map[visit]bool
+}
+
+// visit is a pair of pointers, maps or slices being compared, to detect cycles like reflect.DeepEqual.
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// compare returns the first difference between a and b at path, or "" if they are approximately equal.
+func (c *approxComparer) compare(path string, a, b reflect.Value) string {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() == b.IsValid() {
+			return ""
+		}
+		return mismatch(path, a, b)
+	}
+
+	if a.Type() != b.Type() {
+		return fmt.Sprintf("%s: type %v != %v", pathOrRoot(path), a.Type(), b.Type())
+	}
+
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if !c.close(a.Float(), b.Float()) {
+			return mismatch(path, a, b)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		ca, cb := a.Complex(), b.Complex()
+		if !c.close(real(ca), real(cb)) || !c.close(imag(ca), imag(cb)) {
+			return mismatch(path, a, b)
+		}
+	case reflect.Bool:
+		if a.Bool() != b.Bool() {
+			return mismatch(path, a, b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if a.Int() != b.Int() {
+			return mismatch(path, a, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if a.Uint() != b.Uint() {
+			return mismatch(path, a, b)
+		}
+	case reflect.String:
+		if a.String() != b.String() {
+			return fmt.Sprintf("%s: %q != %q", pathOrRoot(path), a.String(), b.String())
+		}
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return mismatch(path, a, b)
+			}
+			return ""
+		}
+		if a.Pointer() == b.Pointer() || c.seen(a, b) {
+			return ""
+		}
+
+		return c.compare(path, a.Elem(), b.Elem())
+	case reflect.Interface:
+		return c.compare(path, a.Elem(), b.Elem())
+	case reflect.Array, reflect.Slice:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%s: length %d != %d", pathOrRoot(path), a.Len(), b.Len())
+		}
+		if a.Kind() == reflect.Slice && c.seen(a, b) {
+			return ""
+		}
+		for i := 0; i < a.Len(); i++ {
+			if d := c.compare(path+"["+strconv.Itoa(i)+"]", a.Index(i), b.Index(i)); d != "" {
+				return d
+			}
+		}
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%s: length %d != %d", pathOrRoot(path), a.Len(), b.Len())
+		}
+		if c.seen(a, b) {
+			return ""
+		}
+		for _, key := range sortedMapKeys(a) {
+			keyPath := fmt.Sprintf("%s[%#v]", path, key)
+			bv := b.MapIndex(key)
+			if !bv.IsValid() {
+				return fmt.Sprintf("%s: missing in the second value", keyPath)
+			}
+			if d := c.compare(keyPath, a.MapIndex(key), bv); d != "" {
+				return d
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			fieldPath := a.Type().Field(i).Name
+			if path != "" {
+				fieldPath = path + "." + fieldPath
+			}
+			if d := c.compare(fieldPath, a.Field(i), b.Field(i)); d != "" {
+				return d
+			}
+		}
+	case reflect.Func:
+		if !a.IsNil() || !b.IsNil() {
+			return fmt.Sprintf("%s: functions are only equal if both are nil", pathOrRoot(path))
+		}
+	default:
+		// Channels and unsafe pointers are equal if they point to the same thing.
+		if a.Pointer() != b.Pointer() {
+			return mismatch(path, a, b)
+		}
+	}
+
+	return ""
+}
+
+// seen records that the pointers, maps or slices a and b are being compared, and reports whether they already were.
+// Cycles are followed once, a second visit of the same pair is assumed equal.
+func (c *approxComparer) seen(a, b reflect.Value) bool {
+	key := visit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+	if c.visited[key] {
+		return true
+	}
+	c.visited[key] = true
+
+	return false
+}
+
+func (c *approxComparer) close(x, y float64) bool {
+	return Float64IsClose(x, y, c.tolerance, c.tolerance)
+}
+
+func mismatch(path string, a, b reflect.Value) string {
+	return fmt.Sprintf("%s: %v != %v", pathOrRoot(path), formatValue(a), formatValue(b))
+}
+
+// formatValue formats v for a diff. Maps, slices, arrays and structs are only described by their type and length,
+// and pointers and interfaces are followed once, as fmt would recurse forever into a value that contains itself.
+func formatValue(v reflect.Value) string {
+	return describeValue(v, make(map[uintptr]bool))
+}
+
+func describeValue(v reflect.Value, seen map[uintptr]bool) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return describeValue(v.Elem(), seen)
+	case reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+		if seen[v.Pointer()] {
+			return "<cycle>"
+		}
+		seen[v.Pointer()] = true
+		return "&" + describeValue(v.Elem(), seen)
+	case reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			return fmt.Sprintf("%v of length %d", v.Type(), v.Len())
+		}
+	case reflect.Array:
+		return v.Type().String()
+	case reflect.Struct:
+		return v.Type().String() + "{...}"
+	}
+
+	return fmt.Sprint(v)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "value"
+	}
+
+	return path
+}
+
+func sortedMapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	return keys
+}
@@ -0,0 +1,165 @@
+package goutil
+
+import (
+	"math"
+	"testing"
+)
+
+type approxItem struct {
+	Name  string
+	Price float64
+	tags  map[string]float32
+}
+
+type approxResult struct {
+	Items  []approxItem
+	Total  *float64
+	Signal complex128
+	Meta   interface{}
+	Next   *approxResult
+}
+
+func TestApproxEqual(t *testing.T) {
+	total, otherTotal := 10.0, 10.0+1e-12
+	farTotal := 11.0
+
+	base := func() approxResult {
+		return approxResult{
+			Items: []approxItem{
+				{Name: "a", Price: 1.5, tags: map[string]float32{"x": 0.1}},
+				{Name: "b", Price: 8.5},
+			},
+			Total:  &total,
+			Signal: complex(1, -1),
+			Meta:   []int{1, 2},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(r *approxResult)
+		want     bool
+		wantDiff string
+	}{
+		{name: "identical", change: func(r *approxResult) {}, want: true},
+		{name: "within tolerance", change: func(r *approxResult) {
+			r.Items[0].Price += 1e-12
+			r.Total = &otherTotal
+			r.Signal += complex(1e-12, 1e-12)
+			r.Items[0].tags["x"] = 0.1 + 1e-10
+		}, want: true},
+		{name: "float field", change: func(r *approxResult) { r.Items[1].Price = 8.6 }, wantDiff: "Items[1].Price: 8.5 != 8.6"},
+		{name: "string field", change: func(r *approxResult) { r.Items[1].Name = "c" }, wantDiff: `Items[1].Name: "b" != "c"`},
+		{name: "unexported map value", change: func(r *approxResult) { r.Items[0].tags["x"] = 0.2 }, wantDiff: `Items[0].tags["x"]: 0.1 != 0.2`},
+		{name: "missing map key", change: func(r *approxResult) { r.Items[0].tags = map[string]float32{"y": 0.1} }, wantDiff: `Items[0].tags["x"]: missing in the second value`},
+		{name: "slice length", change: func(r *approxResult) { r.Items = r.Items[:1] }, wantDiff: "Items: length 2 != 1"},
+		{name: "pointer", change: func(r *approxResult) { r.Total = &farTotal }, wantDiff: "Total: 10 != 11"},
+		{name: "nil pointer", change: func(r *approxResult) { r.Total = nil }, wantDiff: "Total: &10 != <nil>"},
+		{name: "complex", change: func(r *approxResult) { r.Signal = complex(1, 1) }, wantDiff: "Signal: (1-1i) != (1+1i)"},
+		{name: "interface type", change: func(r *approxResult) { r.Meta = []string{"1"} }, wantDiff: "Meta: type []int != []string"},
+		{name: "interface value", change: func(r *approxResult) { r.Meta = []int{1, 3} }, wantDiff: "Meta[1]: 2 != 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := base(), base()
+			tt.change(&b)
+
+			got, diff := ApproxEqual(a, b, 1e-9)
+			if got != tt.want || diff != tt.wantDiff {
+				t.Errorf("ApproxEqual() = %v, %q, want %v, %q", got, diff, tt.want, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestApproxEqualScalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     interface{}
+		want     bool
+		wantDiff string
+	}{
+		{name: "floats", a: 1e20, b: 1e20 + 1e4, want: true},
+		{name: "float32", a: float32(0.1), b: float32(0.2), wantDiff: "value: 0.1 != 0.2"},
+		{name: "nil", a: nil, b: nil, want: true},
+		{name: "nil and value", a: nil, b: 1, wantDiff: "value: <nil> != 1"},
+		{name: "types", a: 1, b: 1.0, wantDiff: "value: type int != float64"},
+		{name: "nil and empty slice", a: []float64(nil), b: []float64{}, want: true},
+		{name: "arrays", a: [2]float64{1, 2}, b: [2]float64{1, 2.5}, wantDiff: "[1]: 2 != 2.5"},
+		{name: "int map keys", a: map[int]float64{2: 1, 1: 1}, b: map[int]float64{1: 1, 2: 3}, wantDiff: "[2]: 1 != 3"},
+		{name: "NaN", a: math.NaN(), b: math.NaN(), wantDiff: "value: NaN != NaN"},
+		{name: "infinity", a: math.Inf(1), b: math.Inf(1), want: true},
+		{name: "nil funcs", a: (func())(nil), b: (func())(nil), want: true},
+		{name: "funcs", a: func() {}, b: func() {}, wantDiff: "value: functions are only equal if both are nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diff := ApproxEqual(tt.a, tt.b, 1e-9)
+			if got != tt.want || diff != tt.wantDiff {
+				t.Errorf("ApproxEqual(%v, %v) = %v, %q, want %v, %q", tt.a, tt.b, got, diff, tt.want, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestApproxEqualCycles(t *testing.T) {
+	a := &approxResult{Total: new(float64)}
+	a.Next = a
+	b := &approxResult{Total: new(float64)}
+	b.Next = b
+
+	if got, diff := ApproxEqual(a, b, 1e-9); !got {
+		t.Errorf("ApproxEqual() = %v, %q for equal cyclic values, want true", got, diff)
+	}
+
+	*b.Total = 1
+	if got, diff := ApproxEqual(a, b, 1e-9); got || diff != "Total: 0 != 1" {
+		t.Errorf("ApproxEqual() = %v, %q, want false, %q", got, diff, "Total: 0 != 1")
+	}
+}
+
+func TestApproxEqualMapAndSliceCycles(t *testing.T) {
+	a := map[string]interface{}{"v": 1.0}
+	a["x"] = a
+	b := map[string]interface{}{"v": 1.0}
+	b["x"] = b
+
+	if got, diff := ApproxEqual(a, b, 1e-9); !got {
+		t.Errorf("ApproxEqual() = %v, %q for equal cyclic maps, want true", got, diff)
+	}
+
+	b["v"] = 2.0
+	if got, diff := ApproxEqual(a, b, 1e-9); got || diff != `["v"]: 1 != 2` {
+		t.Errorf("ApproxEqual() = %v, %q, want false, %q", got, diff, `["v"]: 1 != 2`)
+	}
+
+	c := map[string]interface{}{"v": 1.0, "x": nil}
+	want := `["x"]: map[string]interface {} of length 2 != <nil>`
+	if got, diff := ApproxEqual(a, c, 1e-9); got || diff != want {
+		t.Errorf("ApproxEqual() = %v, %q, want false, %q", got, diff, want)
+	}
+
+	// A pointer to an interface holding a cyclic map, and a pointer cycle through an interface.
+	var i interface{} = a
+	want = `value: &map[string]interface {} of length 2 != <nil>`
+	if got, diff := ApproxEqual(&i, (*interface{})(nil), 0); got || diff != want {
+		t.Errorf("ApproxEqual() = %v, %q, want false, %q", got, diff, want)
+	}
+	var p interface{}
+	p = &p
+	want = `value: &<cycle> != <nil>`
+	if got, diff := ApproxEqual(&p, (*interface{})(nil), 0); got || diff != want {
+		t.Errorf("ApproxEqual() = %v, %q, want false, %q", got, diff, want)
+	}
+
+	s := []interface{}{1.0, nil}
+	s[1] = s
+	u := []interface{}{1.0, nil}
+	u[1] = u
+
+	if got, diff := ApproxEqual(s, u, 1e-9); !got {
+		t.Errorf("ApproxEqual() = %v, %q for equal cyclic slices, want true", got, diff)
+	}
+}
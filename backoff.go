@@ -0,0 +1,397 @@
+package goutil
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// maxDuration is the longest delay a Backoff returns, delays that would overflow are clamped to it.
+const maxDuration = time.Duration(math.MaxInt64)
+
+// Backoff decides how long to wait between the attempts of a retry loop.
+// NextDelay is called after attempt (counting from 1) has failed and returns the delay before the next attempt,
+// or false if no more attempts should be made.
+// Reset is called before the first attempt so that a Backoff keeping state can start over.
+type Backoff interface {
+	NextDelay(attempt int) (time.Duration, bool)
+	Reset()
+}
+
+// StatefulBackoff is a Backoff that keeps state between the delays of one retry loop.
+// The retry helpers run every loop on a Clone, so a single StatefulBackoff can be shared by concurrent retries.
+type StatefulBackoff interface {
+	Backoff
+	Clone() Backoff
+}
+
+// cloneBackoff returns a copy of b that is safe to use for one retry loop.
+func cloneBackoff(b Backoff) Backoff {
+	if sb, ok := b.(StatefulBackoff); ok {
+		return sb.Clone()
+	}
+
+	return b
+}
+
+// BackoffState is the state of one retry loop over a Backoff: it counts the attempts
+// and owns a copy of any StatefulBackoff, so the Backoff it was created from can be shared.
+// A BackoffState is not safe for concurrent use, each retry loop creates its own.
+type BackoffState struct {
+	backoff Backoff
+	attempt int
+}
+
+// NewBackoffState returns the state of a new retry loop over b, before its first attempt.
+func NewBackoffState(b Backoff) *BackoffState {
+	b = cloneBackoff(b)
+	b.Reset()
+
+	return &BackoffState{backoff: b}
+}
+
+// Next records a failed attempt and returns the delay before the next one, or false if no more attempts should be made.
+func (s *BackoffState) Next() (time.Duration, bool) {
+	s.attempt++
+
+	return s.backoff.NextDelay(s.attempt)
+}
+
+// Attempts returns the number of attempts recorded by Next.
+func (s *BackoffState) Attempts() int {
+	return s.attempt
+}
+
+// allows reports whether waiting d before the next attempt stays within the MaxDelay and MaxElapsedTime limits
+// wrapped around the backoff, so that a delay hinted by the failed attempt cannot bypass them.
+func (s *BackoffState) allows(d time.Duration) bool {
+	b := s.backoff
+	for {
+		switch w := b.(type) {
+		case *maxDelayBackoff:
+			if d > w.limit {
+				return false
+			}
+			b = w.Backoff
+		case *maxElapsedTimeBackoff:
+			if w.clock.Now().Sub(w.start)+d > w.limit {
+				return false
+			}
+			b = w.Backoff
+		case *maxRetriesBackoff:
+			b = w.Backoff
+		default:
+			return true
+		}
+	}
+}
+
+// ConstantBackoff waits Delay before every retry.
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay returns Delay for every attempt.
+func (b ConstantBackoff) NextDelay(int) (time.Duration, bool) {
+	return b.Delay, true
+}
+
+// Reset does nothing, a ConstantBackoff keeps no state.
+func (ConstantBackoff) Reset() {}
+
+// LinearBackoff waits Initial before the first retry and Increment longer before each following one.
+type LinearBackoff struct {
+	Initial   time.Duration
+	Increment time.Duration
+}
+
+// NextDelay returns Initial + Increment * (attempt-1).
+func (b LinearBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	delay := b.Initial + scaleDuration(b.Increment, float64(attempt-1))
+	if delay < b.Initial {
+		return maxDuration, true
+	}
+
+	return delay, true
+}
+
+// Reset does nothing, a LinearBackoff keeps no state.
+func (LinearBackoff) Reset() {}
+
+// ExponentialBackoff waits Initial before the first retry and Multiplier times longer before each following one.
+// A Multiplier of 0 defaults to 2.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Multiplier float64
+}
+
+// NextDelay returns Initial * Multiplier^(attempt-1).
+func (b ExponentialBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	return scaleDuration(b.Initial, math.Pow(multiplier, float64(attempt-1))), true
+}
+
+// Reset does nothing, an ExponentialBackoff keeps no state.
+func (ExponentialBackoff) Reset() {}
+
+// FibonacciBackoff waits Initial times the Fibonacci numbers 1, 1, 2, 3, 5, ... before successive retries.
+type FibonacciBackoff struct {
+	Initial time.Duration
+}
+
+// NextDelay returns Initial times the attempt-th Fibonacci number.
+func (b FibonacciBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	prev, cur := 0.0, 1.0
+	for i := 1; i < attempt && !math.IsInf(cur, 1); i++ {
+		prev, cur = cur, prev+cur
+	}
+
+	return scaleDuration(b.Initial, cur), true
+}
+
+// Reset does nothing, a FibonacciBackoff keeps no state.
+func (FibonacciBackoff) Reset() {}
+
+// FullJitterBackoff waits a random delay between 0 and the exponential delay Base * 2^(attempt-1), capped at Cap.
+// It is the "Full Jitter" strategy of the AWS Architecture Blog post "Exponential Backoff And Jitter".
+// A Cap of 0 means no cap.
+type FullJitterBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+	Rand JitterSource
+}
+
+// NextDelay returns a random delay between 0 and Base * 2^(attempt-1), capped at Cap.
+func (b FullJitterBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	return randomDuration(b.Rand, 0, cappedExponential(b.Base, b.Cap, attempt)), true
+}
+
+// Reset does nothing, a FullJitterBackoff keeps no state.
+func (FullJitterBackoff) Reset() {}
+
+// EqualJitterBackoff waits half of the exponential delay Base * 2^(attempt-1), capped at Cap,
+// plus a random delay up to the other half. It is the "Equal Jitter" strategy of the AWS Architecture Blog.
+// A Cap of 0 means no cap.
+type EqualJitterBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+	Rand JitterSource
+}
+
+// NextDelay returns half of Base * 2^(attempt-1), capped at Cap, plus a random delay up to the other half.
+func (b EqualJitterBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	half := cappedExponential(b.Base, b.Cap, attempt) / 2
+	return half + randomDuration(b.Rand, 0, half), true
+}
+
+// Reset does nothing, an EqualJitterBackoff keeps no state.
+func (EqualJitterBackoff) Reset() {}
+
+// DecorrelatedJitterBackoff waits a random delay between Base and three times the previous delay, capped at Cap.
+// It is the "Decorrelated Jitter" strategy of the AWS Architecture Blog.
+// It remembers the previous delay, so it must be used as a pointer. A Cap of 0 means no cap.
+// DecorrelatedJitterBackoff is a StatefulBackoff.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+	Rand JitterSource
+
+	prev time.Duration
+}
+
+// NextDelay returns a random delay in [Base, 3 * previous delay), capped at Cap, and remembers it.
+func (b *DecorrelatedJitterBackoff) NextDelay(int) (time.Duration, bool) {
+	prev := b.prev
+	if prev < b.Base {
+		prev = b.Base
+	}
+
+	delay := randomDuration(b.Rand, b.Base, scaleDuration(prev, 3))
+	if b.Cap > 0 && delay > b.Cap {
+		delay = b.Cap
+	}
+
+	b.prev = delay
+
+	return delay, true
+}
+
+// Reset forgets the previous delay, so that the next one starts again from Base.
+func (b *DecorrelatedJitterBackoff) Reset() {
+	b.prev = 0
+}
+
+// Clone returns a DecorrelatedJitterBackoff with the same settings and no previous delay.
+func (b *DecorrelatedJitterBackoff) Clone() Backoff {
+	return &DecorrelatedJitterBackoff{Base: b.Base, Cap: b.Cap, Rand: b.Rand}
+}
+
+// NextDelay returns the delay before the attempt following attempt, or false once TotalRuns attempts have been made.
+// The delay is BaseDuration multiplied by Factor once per previous retry, plus up to JitterFactor of it at random.
+func (b BackoffWait) NextDelay(attempt int) (time.Duration, bool) {
+	if attempt >= b.TotalRuns {
+		return 0, false
+	}
+
+	delay := b.BaseDuration
+	if b.Factor != 0 {
+		delay = scaleDuration(b.BaseDuration, math.Pow(b.Factor, float64(attempt-1)))
+	}
+
+	if b.JitterFactor > 0 {
+		delay = addJitter(b.Rand, delay, b.JitterFactor)
+	}
+
+	return delay, true
+}
+
+// Reset does nothing, a BackoffWait keeps no state between attempts.
+func (BackoffWait) Reset() {}
+
+// MaxDelay caps every delay of b at limit.
+func MaxDelay(b Backoff, limit time.Duration) Backoff {
+	return &maxDelayBackoff{Backoff: b, limit: limit}
+}
+
+type maxDelayBackoff struct {
+	Backoff
+	limit time.Duration
+}
+
+func (b *maxDelayBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	delay, ok := b.Backoff.NextDelay(attempt)
+	if delay > b.limit {
+		delay = b.limit
+	}
+
+	return delay, ok
+}
+
+func (b *maxDelayBackoff) Clone() Backoff {
+	return &maxDelayBackoff{Backoff: cloneBackoff(b.Backoff), limit: b.limit}
+}
+
+// MaxRetries stops b after limit retries, that is after limit+1 attempts.
+func MaxRetries(b Backoff, limit int) Backoff {
+	return &maxRetriesBackoff{Backoff: b, limit: limit}
+}
+
+type maxRetriesBackoff struct {
+	Backoff
+	limit int
+}
+
+func (b *maxRetriesBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	if attempt > b.limit {
+		return 0, false
+	}
+
+	return b.Backoff.NextDelay(attempt)
+}
+
+func (b *maxRetriesBackoff) Clone() Backoff {
+	return &maxRetriesBackoff{Backoff: cloneBackoff(b.Backoff), limit: b.limit}
+}
+
+// MaxElapsedTime stops b once the next retry would start more than limit after the first attempt.
+// The time is measured from the last call to Reset on RealClock, WithMaxElapsedTime follows the clock of the retry.
+func MaxElapsedTime(b Backoff, limit time.Duration) Backoff {
+	return &maxElapsedTimeBackoff{Backoff: b, limit: limit, clock: RealClock{}}
+}
+
+type maxElapsedTimeBackoff struct {
+	Backoff
+	limit time.Duration
+	clock Clock
+	start time.Time
+}
+
+func (b *maxElapsedTimeBackoff) NextDelay(attempt int) (time.Duration, bool) {
+	now := b.clock.Now()
+	if b.start.IsZero() {
+		b.start = now
+	}
+
+	delay, ok := b.Backoff.NextDelay(attempt)
+	if !ok || now.Sub(b.start)+delay > b.limit {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+func (b *maxElapsedTimeBackoff) Reset() {
+	b.start = b.clock.Now()
+	b.Backoff.Reset()
+}
+
+func (b *maxElapsedTimeBackoff) Clone() Backoff {
+	return &maxElapsedTimeBackoff{Backoff: cloneBackoff(b.Backoff), limit: b.limit, clock: b.clock}
+}
+
+// scaleDuration multiplies d by factor, clamping the result to maxDuration.
+func scaleDuration(d time.Duration, factor float64) time.Duration {
+	f := float64(d) * factor
+	if f >= float64(maxDuration) {
+		return maxDuration
+	}
+
+	return time.Duration(f)
+}
+
+// cappedExponential returns base * 2^(attempt-1), capped at limit unless limit is 0.
+func cappedExponential(base, limit time.Duration, attempt int) time.Duration {
+	d := scaleDuration(base, math.Pow(2, float64(attempt-1)))
+	if limit > 0 && d > limit {
+		d = limit
+	}
+
+	return d
+}
+
+// randomDuration returns a random duration in [lo, hi) drawn from src.
+func randomDuration(src JitterSource, lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+
+	return lo + time.Duration(randFloat64(src)*float64(hi-lo))
+}
+
+// JitterSource is the source of randomness for jitter, Float64 returns a number in [0.0, 1.0).
+// A nil JitterSource uses the global source of math/rand.
+// *rand.Rand implements JitterSource but is not safe for concurrent use, NewJitterSource returns one that is.
+type JitterSource interface {
+	Float64() float64
+}
+
+// NewJitterSource returns a JitterSource seeded with seed, so that jittered delays can be reproduced in tests.
+// It is safe for concurrent use.
+func NewJitterSource(seed int64) JitterSource {
+	return &lockedSource{r: rand.New(rand.NewSource(seed))}
+}
+
+type lockedSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (s *lockedSource) Float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.r.Float64()
+}
+
+func randFloat64(src JitterSource) float64 {
+	if src == nil {
+		return rand.Float64()
+	}
+
+	return src.Float64()
+}
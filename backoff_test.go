@@ -0,0 +1,165 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{
+			name:    "constant",
+			backoff: ConstantBackoff{Delay: time.Second},
+			want:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:    "linear",
+			backoff: LinearBackoff{Initial: time.Second, Increment: 500 * time.Millisecond},
+			want:    []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second},
+		},
+		{
+			name:    "exponential",
+			backoff: ExponentialBackoff{Initial: time.Second, Multiplier: 3},
+			want:    []time.Duration{time.Second, 3 * time.Second, 9 * time.Second},
+		},
+		{
+			name:    "exponential default multiplier",
+			backoff: ExponentialBackoff{Initial: time.Second},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:    "fibonacci",
+			backoff: FibonacciBackoff{Initial: time.Second},
+			want:    []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second},
+		},
+		{
+			name:    "backoff wait",
+			backoff: BackoffWait{TotalRuns: 4, BaseDuration: time.Second, Factor: 2},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:    "max delay",
+			backoff: MaxDelay(ExponentialBackoff{Initial: time.Second}, 3*time.Second),
+			want:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:    "max retries",
+			backoff: MaxRetries(ConstantBackoff{Delay: time.Second}, 2),
+			want:    []time.Duration{time.Second, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.backoff.Reset()
+			for i, want := range tt.want {
+				got, ok := tt.backoff.NextDelay(i + 1)
+				if !ok || got != want {
+					t.Errorf("NextDelay(%d) = %v, %v, want %v, true", i+1, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffStops(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		attempt int
+	}{
+		{name: "backoff wait total runs", backoff: BackoffWait{TotalRuns: 3, BaseDuration: time.Second}, attempt: 3},
+		{name: "max retries", backoff: MaxRetries(ConstantBackoff{Delay: time.Second}, 2), attempt: 3},
+		{name: "max elapsed time", backoff: MaxElapsedTime(ConstantBackoff{Delay: time.Hour}, time.Minute), attempt: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.backoff.Reset()
+			if _, ok := tt.backoff.NextDelay(tt.attempt); ok {
+				t.Errorf("NextDelay(%d) should stop the retries", tt.attempt)
+			}
+		})
+	}
+}
+
+func TestJitterBackoffRanges(t *testing.T) {
+	base, limit := 100*time.Millisecond, time.Second
+
+	full := FullJitterBackoff{Base: base, Cap: limit}
+	equal := EqualJitterBackoff{Base: base, Cap: limit}
+	decorrelated := &DecorrelatedJitterBackoff{Base: base, Cap: limit}
+	decorrelated.Reset()
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := time.Duration(float64(base) * math.Pow(2, float64(attempt-1)))
+		if ceiling > limit {
+			ceiling = limit
+		}
+
+		if d, _ := full.NextDelay(attempt); d < 0 || d > ceiling {
+			t.Errorf("FullJitterBackoff.NextDelay(%d) = %v, want in [0, %v]", attempt, d, ceiling)
+		}
+		if d, _ := equal.NextDelay(attempt); d < ceiling/2 || d > ceiling {
+			t.Errorf("EqualJitterBackoff.NextDelay(%d) = %v, want in [%v, %v]", attempt, d, ceiling/2, ceiling)
+		}
+		if d, _ := decorrelated.NextDelay(attempt); d < base || d > limit {
+			t.Errorf("DecorrelatedJitterBackoff.NextDelay(%d) = %v, want in [%v, %v]", attempt, d, base, limit)
+		}
+	}
+}
+
+func TestBackoffOverflow(t *testing.T) {
+	if d, _ := (ExponentialBackoff{Initial: time.Hour}).NextDelay(1000); d != maxDuration {
+		t.Errorf("Expected overflowing delay to be clamped, got %v", d)
+	}
+	if d, _ := (FibonacciBackoff{Initial: time.Hour}).NextDelay(5000); d != maxDuration {
+		t.Errorf("Expected overflowing delay to be clamped, got %v", d)
+	}
+}
+
+func TestRetryWithBackoffStrategy(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+
+	calls := 0
+	_, err := Retry(context.Background(), MaxRetries(ConstantBackoff{Delay: time.Millisecond}, 4), func(context.Context) (int, error) {
+		calls++
+		return 0, ErrCustom
+	})
+
+	if !errors.Is(err, ErrCustom) {
+		t.Errorf("Retry() error = %v, want %v", err, ErrCustom)
+	}
+	if calls != 5 {
+		t.Errorf("Retry() called fn %d times, want 5", calls)
+	}
+}
+
+func TestBackoffState(t *testing.T) {
+	shared := &DecorrelatedJitterBackoff{Base: time.Second, Cap: time.Minute, Rand: NewJitterSource(1)}
+	backoff := MaxRetries(shared, 2)
+
+	a, b := NewBackoffState(backoff), NewBackoffState(backoff)
+	for i := 0; i < 2; i++ {
+		if _, ok := a.Next(); !ok {
+			t.Fatalf("Next() stopped after %d attempts, want 2 retries", a.Attempts())
+		}
+	}
+
+	if _, ok := a.Next(); ok || a.Attempts() != 3 {
+		t.Errorf("Expected Next to stop after 3 attempts, got ok = %v after %d", ok, a.Attempts())
+	}
+	if b.Attempts() != 0 || shared.prev != 0 {
+		t.Errorf("Expected the states to be independent of each other and of the shared backoff")
+	}
+	if _, ok := b.Next(); !ok {
+		t.Errorf("Expected a fresh state to allow a retry")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 )
 
 var ErrInvalidBatchType = errors.New("invalid batch type")
@@ -20,12 +21,14 @@ type BatchProcessor interface {
 }
 
 // BatchProcess performs the batch processing.
+// Every batch is reported to the Metrics under the operation of ctx, see ContextWithOperation.
 func BatchProcess(ctx context.Context, items interface{}, batchSize int, concurrency int, processor BatchProcessor) (BatchResult, error) {
 	var wg sync.WaitGroup
 	batches := processor.SplitBatch(items, batchSize)
 	ch := make(chan BatchResult, len(batches))
 	errCh := make(chan error, len(batches))
 	sem := make(chan struct{}, concurrency)
+	metrics := newMetricsReporter(OperationFromContext(ctx))
 
 	for _, batch := range batches {
 		wg.Add(1)
@@ -36,8 +39,12 @@ func BatchProcess(ctx context.Context, items interface{}, batchSize int, concurr
 			defer func() { <-sem }() // Release a token
 
 			sem <- struct{}{} // Acquire a token
+			start := time.Now()
 			result, err := processor.Process(ctx, batch)
+			metrics.inc(MetricBatches)
+			metrics.observe(MetricBatchDurationSeconds, time.Since(start).Seconds())
 			if err != nil {
+				metrics.inc(MetricBatchErrors)
 				errCh <- err
 				return
 			}
@@ -0,0 +1,359 @@
+package goutil
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a CircuitBreaker rejects a call, because it is open
+// or because it is half-open and all its probes are in flight.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// StateClosed lets every call through and counts failures.
+	StateClosed CircuitState = iota
+	// StateOpen rejects every call until the cooldown has passed.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through to test whether the dependency recovered.
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerSettings configures a CircuitBreaker. Zero values select the documented defaults.
+//
+// The breaker opens when either threshold is reached:
+// ConsecutiveFailures failures in a row, or a failure ratio of at least FailureRate
+// among the calls of the last Window, once at least MinRequests calls were made in it.
+// If no threshold is set, ConsecutiveFailures defaults to 5.
+type CircuitBreakerSettings struct {
+	ConsecutiveFailures int
+	FailureRate         float64
+	MinRequests         int           // defaults to 10
+	Window              time.Duration // defaults to 10s, tracked in 10 buckets
+	Cooldown            time.Duration // time spent open before probing, defaults to 30s
+	HalfOpenProbes      int           // successful probes needed to close again, defaults to 1
+	ProbeTimeout        time.Duration // time after which a probe still in flight counts as failed, defaults to Cooldown
+
+	// IsFailure reports whether the error of a call counts as a failure. It defaults to err != nil.
+	IsFailure func(err error) bool
+	// OnStateChange is called after every state change, outside of the breaker's lock.
+	OnStateChange func(from, to CircuitState)
+	// Clock defaults to RealClock.
+	Clock Clock
+}
+
+const circuitWindowBuckets = 10
+
+// CircuitBreaker stops calls to a dependency that keeps failing, giving it time to recover.
+// It composes with the retry helpers through WithCircuitBreaker.
+// A CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64
+	openedAt    time.Time
+	consecutive int
+	window      rollingWindow
+	probes      map[uint64]time.Time // start of the probes in flight while half-open
+	lastProbe   uint64
+	probeOKs    int // successful probes while half-open
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.ConsecutiveFailures <= 0 && settings.FailureRate <= 0 {
+		settings.ConsecutiveFailures = 5
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 10
+	}
+	if settings.Window <= 0 {
+		settings.Window = 10 * time.Second
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	if settings.ProbeTimeout <= 0 {
+		settings.ProbeTimeout = settings.Cooldown
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(err error) bool { return err != nil }
+	}
+	if settings.Clock == nil {
+		settings.Clock = RealClock{}
+	}
+
+	return &CircuitBreaker{
+		settings: settings,
+		window:   newRollingWindow(settings.Window, circuitWindowBuckets),
+	}
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	state, changed := cb.currentState(cb.settings.Clock.Now())
+	cb.mu.Unlock()
+
+	cb.notify(changed)
+
+	return state
+}
+
+// Execute calls fn if the breaker allows it and records its result.
+// It returns ErrCircuitOpen without calling fn if the breaker rejects the call.
+// If fn panics, the call is recorded as a failure and the panic continues.
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	record, err := cb.allow()
+	if err != nil {
+		return err
+	}
+
+	return cb.guard(record, fn)
+}
+
+// Allow reports whether a call may be made now. If it may, the returned function must be called
+// with the error of the call once it has finished.
+// It returns ErrCircuitOpen if the breaker rejects the call.
+// While the breaker is half-open, a call that has not called done within ProbeTimeout counts as a failure.
+func (cb *CircuitBreaker) Allow() (done func(err error), err error) {
+	record, err := cb.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) {
+		record(cb.settings.IsFailure(err))
+	}, nil
+}
+
+// allow is Allow, with a returned function taking whether the call failed.
+func (cb *CircuitBreaker) allow() (record func(failure bool), err error) {
+	cb.mu.Lock()
+	now := cb.settings.Clock.Now()
+	state, changed := cb.currentState(now)
+
+	var probe uint64
+	switch state {
+	case StateOpen:
+		err = ErrCircuitOpen
+	case StateHalfOpen:
+		if len(cb.probes)+cb.probeOKs >= cb.settings.HalfOpenProbes {
+			err = ErrCircuitOpen
+		} else {
+			cb.lastProbe++
+			probe = cb.lastProbe
+			if cb.probes == nil {
+				cb.probes = make(map[uint64]time.Time)
+			}
+			cb.probes[probe] = now
+		}
+	}
+
+	generation := cb.generation
+	cb.mu.Unlock()
+
+	cb.notify(changed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(failure bool) {
+		once.Do(func() {
+			cb.record(generation, probe, failure)
+		})
+	}, nil
+}
+
+// guard calls fn and passes its result to record. A panic of fn is recorded as a failure and not recovered.
+func (cb *CircuitBreaker) guard(record func(failure bool), fn func() error) error {
+	failure := true
+	defer func() { record(failure) }()
+
+	err := fn()
+	failure = cb.settings.IsFailure(err)
+
+	return err
+}
+
+// record counts the result of a call allowed in generation, as probe if it was allowed while half-open.
+// Results from a previous generation, including those of probes that timed out, are ignored.
+func (cb *CircuitBreaker) record(generation, probe uint64, failure bool) {
+	cb.mu.Lock()
+	now := cb.settings.Clock.Now()
+	state, changed := cb.currentState(now)
+
+	if generation == cb.generation {
+		switch state {
+		case StateClosed:
+			cb.window.add(now, failure)
+			if failure {
+				cb.consecutive++
+			} else {
+				cb.consecutive = 0
+			}
+
+			if cb.tripped(now) {
+				changed = append(changed, cb.setState(StateOpen, now))
+			}
+		case StateHalfOpen:
+			delete(cb.probes, probe)
+			if failure {
+				changed = append(changed, cb.setState(StateOpen, now))
+			} else if cb.probeOKs++; cb.probeOKs >= cb.settings.HalfOpenProbes {
+				changed = append(changed, cb.setState(StateClosed, now))
+			}
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.notify(changed)
+}
+
+// tripped reports whether a threshold of the closed state is reached. cb.mu must be held.
+func (cb *CircuitBreaker) tripped(now time.Time) bool {
+	if cb.settings.ConsecutiveFailures > 0 && cb.consecutive >= cb.settings.ConsecutiveFailures {
+		return true
+	}
+
+	if cb.settings.FailureRate > 0 {
+		successes, failures := cb.window.counts(now)
+		total := successes + failures
+		return total >= cb.settings.MinRequests && float64(failures)/float64(total) >= cb.settings.FailureRate
+	}
+
+	return false
+}
+
+// currentState moves an open breaker to half-open once its cooldown has passed,
+// and a half-open breaker back to open once a probe has been in flight for ProbeTimeout. cb.mu must be held.
+func (cb *CircuitBreaker) currentState(now time.Time) (CircuitState, []stateChange) {
+	var changed []stateChange
+	if cb.state == StateOpen && now.Sub(cb.openedAt) >= cb.settings.Cooldown {
+		changed = append(changed, cb.setState(StateHalfOpen, now))
+	}
+
+	if cb.state == StateHalfOpen {
+		for _, start := range cb.probes {
+			if now.Sub(start) >= cb.settings.ProbeTimeout {
+				changed = append(changed, cb.setState(StateOpen, now))
+				break
+			}
+		}
+	}
+
+	return cb.state, changed
+}
+
+type stateChange struct {
+	from, to CircuitState
+}
+
+// setState switches to state and starts a new generation. cb.mu must be held.
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) stateChange {
+	change := stateChange{from: cb.state, to: state}
+
+	cb.state = state
+	cb.generation++
+	cb.consecutive = 0
+	cb.probes = nil
+	cb.probeOKs = 0
+	cb.window.reset()
+
+	if state == StateOpen {
+		cb.openedAt = now
+	}
+
+	return change
+}
+
+func (cb *CircuitBreaker) notify(changes []stateChange) {
+	if cb.settings.OnStateChange == nil {
+		return
+	}
+
+	for _, c := range changes {
+		cb.settings.OnStateChange(c.from, c.to)
+	}
+}
+
+// rollingWindow counts successes and failures over the last size of time, in buckets.
+type rollingWindow struct {
+	size    time.Duration
+	width   time.Duration
+	buckets []windowBucket
+}
+
+type windowBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+func newRollingWindow(size time.Duration, n int) rollingWindow {
+	width := size / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+
+	return rollingWindow{size: size, width: width, buckets: make([]windowBucket, n)}
+}
+
+func (w *rollingWindow) add(now time.Time, failure bool) {
+	start := now.Truncate(w.width)
+	i := (start.UnixNano() / int64(w.width)) % int64(len(w.buckets))
+	if i < 0 {
+		i += int64(len(w.buckets))
+	}
+
+	b := &w.buckets[i]
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+
+	if failure {
+		b.failures++
+	} else {
+		b.successes++
+	}
+}
+
+func (w *rollingWindow) counts(now time.Time) (successes, failures int) {
+	for _, b := range w.buckets {
+		if !b.start.IsZero() && now.Sub(b.start) < w.size {
+			successes += b.successes
+			failures += b.failures
+		}
+	}
+
+	return successes, failures
+}
+
+func (w *rollingWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = windowBucket{}
+	}
+}
@@ -0,0 +1,220 @@
+package goutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qcrao/goutil"
+	"github.com/qcrao/goutil/goutiltest"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func TestCircuitBreakerConsecutiveFailures(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+
+	var changes []string
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{
+		ConsecutiveFailures: 3,
+		Cooldown:            time.Minute,
+		HalfOpenProbes:      2,
+		Clock:               clock,
+		OnStateChange: func(from, to goutil.CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+
+	fail := func() error { return errUnavailable }
+	succeed := func() error { return nil }
+
+	for i := 0; i < 3; i++ {
+		if err := cb.Execute(fail); err != errUnavailable {
+			t.Fatalf("Execute() error = %v, want %v", err, errUnavailable)
+		}
+	}
+	if cb.State() != goutil.StateOpen {
+		t.Fatalf("Expected the breaker to open, got %v", cb.State())
+	}
+	if err := cb.Execute(succeed); !errors.Is(err, goutil.ErrCircuitOpen) {
+		t.Fatalf("Execute() error = %v, want %v", err, goutil.ErrCircuitOpen)
+	}
+
+	clock.Advance(time.Minute)
+	if cb.State() != goutil.StateHalfOpen {
+		t.Fatalf("Expected the breaker to be half-open after the cooldown, got %v", cb.State())
+	}
+
+	// Only HalfOpenProbes calls may be in flight while half-open.
+	done1, err1 := cb.Allow()
+	done2, err2 := cb.Allow()
+	_, err3 := cb.Allow()
+	if err1 != nil || err2 != nil || !errors.Is(err3, goutil.ErrCircuitOpen) {
+		t.Fatalf("Expected 2 probes to be allowed, got %v, %v, %v", err1, err2, err3)
+	}
+	done1(nil)
+	done2(nil)
+
+	if cb.State() != goutil.StateClosed {
+		t.Fatalf("Expected successful probes to close the breaker, got %v", cb.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected state changes %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Expected state changes %v, got %v", want, changes)
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{ConsecutiveFailures: 1, Cooldown: time.Second, Clock: clock})
+
+	_ = cb.Execute(func() error { return errUnavailable })
+	clock.Advance(time.Second)
+	_ = cb.Execute(func() error { return errUnavailable })
+
+	if cb.State() != goutil.StateOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpenPanic(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{ConsecutiveFailures: 1, Cooldown: time.Second, Clock: clock})
+
+	panicking := map[string]func(){
+		"Execute": func() {
+			_ = cb.Execute(func() error { panic("boom") })
+		},
+		"WithCircuitBreaker": func() {
+			_ = goutil.RetryWithContext(context.Background(), goutil.NewNoRetry(), func(context.Context) (bool, error) {
+				panic("boom")
+			}, goutil.WithCircuitBreaker(cb))
+		},
+	}
+
+	for name, fn := range panicking {
+		_ = cb.Execute(func() error { return errUnavailable })
+		clock.Advance(time.Second)
+		if cb.State() != goutil.StateHalfOpen {
+			t.Fatalf("%s: expected the breaker to be half-open, got %v", name, cb.State())
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("%s: expected the panic to continue, recovered %v", name, r)
+				}
+			}()
+			fn()
+		}()
+
+		if cb.State() != goutil.StateOpen {
+			t.Fatalf("%s: expected a panicking probe to reopen the breaker, got %v", name, cb.State())
+		}
+
+		clock.Advance(time.Second)
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Fatalf("%s: expected a new probe after the cooldown, got %v", name, err)
+		}
+		if cb.State() != goutil.StateClosed {
+			t.Fatalf("%s: expected a successful probe to close the breaker, got %v", name, cb.State())
+		}
+	}
+}
+
+func TestCircuitBreakerProbeTimeout(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{
+		ConsecutiveFailures: 1,
+		Cooldown:            time.Second,
+		ProbeTimeout:        5 * time.Second,
+		Clock:               clock,
+	})
+
+	_ = cb.Execute(func() error { return errUnavailable })
+	clock.Advance(time.Second)
+
+	// A probe whose done is never called.
+	if _, err := cb.Allow(); err != nil {
+		t.Fatalf("Allow() error = %v, want a probe", err)
+	}
+	if _, err := cb.Allow(); !errors.Is(err, goutil.ErrCircuitOpen) {
+		t.Fatalf("Allow() error = %v, want %v while the probe is in flight", err, goutil.ErrCircuitOpen)
+	}
+
+	clock.Advance(5 * time.Second)
+	if cb.State() != goutil.StateOpen {
+		t.Fatalf("Expected an abandoned probe to reopen the breaker after ProbeTimeout, got %v", cb.State())
+	}
+
+	clock.Advance(time.Second)
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("Execute() error = %v, want a new probe after the cooldown", err)
+	}
+	if cb.State() != goutil.StateClosed {
+		t.Fatalf("Expected a successful probe to close the breaker, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Window:      10 * time.Second,
+		Clock:       clock,
+	})
+
+	results := []error{errUnavailable, nil, errUnavailable}
+	for _, res := range results {
+		res := res
+		_ = cb.Execute(func() error { return res })
+	}
+	if cb.State() != goutil.StateClosed {
+		t.Fatalf("Expected the breaker to stay closed below MinRequests, got %v", cb.State())
+	}
+
+	// Calls older than the window no longer count.
+	clock.Advance(11 * time.Second)
+	_ = cb.Execute(func() error { return errUnavailable })
+	if cb.State() != goutil.StateClosed {
+		t.Fatalf("Expected expired calls not to count, got %v", cb.State())
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(func() error { return nil })
+	}
+	_ = cb.Execute(func() error { return errUnavailable })
+	if cb.State() != goutil.StateClosed {
+		t.Fatalf("Expected a 40%% failure rate to keep the breaker closed, got %v", cb.State())
+	}
+
+	_ = cb.Execute(func() error { return errUnavailable })
+	if cb.State() != goutil.StateOpen {
+		t.Fatalf("Expected a 50%% failure rate to open the breaker, got %v", cb.State())
+	}
+}
+
+func TestRetryWithCircuitBreaker(t *testing.T) {
+	cb := goutil.NewCircuitBreaker(goutil.CircuitBreakerSettings{ConsecutiveFailures: 2, Cooldown: time.Hour})
+
+	calls := 0
+	err := goutil.RetryWithContext(context.Background(), goutil.ConstantBackoff{Delay: time.Millisecond}, func(context.Context) (bool, error) {
+		calls++
+		return false, errUnavailable
+	}, goutil.WithCircuitBreaker(cb))
+
+	if !errors.Is(err, goutil.ErrCircuitOpen) || !errors.Is(err, errUnavailable) {
+		t.Errorf("RetryWithContext() error = %v, want ErrCircuitOpen and %v", err, errUnavailable)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the open breaker to stop the retries after 2 calls, got %d", calls)
+	}
+}
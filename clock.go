@@ -0,0 +1,52 @@
+package goutil
+
+import "time"
+
+// Clock abstracts the passage of time so that code waiting on timers can be tested without sleeping.
+// RealClock is backed by the time package, goutiltest.FakeClock is advanced by hand.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	After(d time.Duration) <-chan time.Time
+}
+
+// Timer is the part of *time.Timer that is available through a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// RealClock is a Clock that uses the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a Timer backed by time.NewTimer.
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// After returns time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
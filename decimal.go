@@ -0,0 +1,306 @@
+package goutil
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// maxDecimalExponent bounds the exponent accepted by ParseDecimal, so that a short input such as "1e999999999"
+// cannot allocate a huge number.
+const maxDecimalExponent = 10000
+
+// Decimal is an exact decimal number: an arbitrary precision integer divided by 10^Scale.
+// It is meant for amounts of money and other values that must not suffer from binary floating-point errors.
+//
+// Decimal is an immutable value, safe for concurrent use, and its zero value is 0.
+// Add, Sub and Mul are exact. Div and Round take the scale of the result and a RoundingMode.
+// The scale is kept through arithmetic, so "1.50" stays "1.50"; Cmp and Equal compare numeric values.
+type Decimal struct {
+	value *big.Int // never modified once set, nil means 0
+	scale int
+}
+
+// NewDecimal returns unscaled / 10^scale, for example NewDecimal(150, 2) is 1.50.
+// A negative scale multiplies: NewDecimal(15, -2) is 1500.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return NewDecimalFromBigInt(big.NewInt(unscaled), scale)
+}
+
+// NewDecimalFromBigInt returns unscaled / 10^scale. It does not keep a reference to unscaled.
+func NewDecimalFromBigInt(unscaled *big.Int, scale int) Decimal {
+	value := new(big.Int).Set(unscaled)
+	if scale < 0 {
+		value.Mul(value, pow10(-scale))
+		scale = 0
+	}
+
+	return Decimal{value: value, scale: scale}
+}
+
+// NewDecimalFromFloat returns the shortest decimal that converts back to f, for example 0.1 rather than
+// 0.1000000000000000055511151231257827. It returns ErrInvalidDecimal for NaN and infinities.
+func NewDecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrInvalidDecimal, f)
+	}
+
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// ParseDecimal parses a decimal number such as "123", "-0.50" or "1.5e-3". The scale of the result is the
+// number of digits after the point, adjusted by the exponent: "0.50" has scale 2, "1.5e-3" has scale 4.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		if e > maxDecimalExponent || e < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("%w: exponent out of range in %q", ErrInvalidDecimal, s)
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	digits := integer + fraction
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	value, _ := new(big.Int).SetString(sign+digits, 10)
+
+	return NewDecimalFromBigInt(value, len(fraction)-exp), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a valid decimal.
+// It is meant for constants, such as MustParseDecimal("0.01").
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Decimal) int() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+
+	return d.value
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Unscaled returns a copy of the integer that d is made of: d = Unscaled / 10^Scale.
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.int())
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + other, with the larger scale of both.
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - other, with the larger scale of both.
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * other, with the sum of their scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+// Div returns d / other rounded to scale digits after the point with mode.
+// It returns ErrDivisionByZero if other is 0.
+func (d Decimal) Div(other Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if other.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// d / other = (a / 10^sa) / (b / 10^sb), so its unscaled value at scale is a * 10^(scale+sb-sa) / b.
+	n, den := new(big.Int).Set(d.int()), new(big.Int).Set(other.int())
+	if shift := scale + other.scale - d.scale; shift >= 0 {
+		n.Mul(n, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	return NewDecimalFromBigInt(roundQuo(n, den, mode), scale), nil
+}
+
+// Round returns d rounded to scale digits after the point with mode. A negative scale rounds to tens,
+// hundreds and so on: Round(-2, RoundHalfEven) rounds 1250 to 1200.
+// If scale is larger than the scale of d, Round adds trailing zeros.
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	if scale >= d.scale {
+		return Decimal{value: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+	}
+
+	value := roundQuo(d.int(), pow10(d.scale-scale), mode)
+
+	return NewDecimalFromBigInt(value, scale)
+}
+
+// Cmp compares the values of d and other, ignoring their scales, and returns -1, 0 or 1.
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and other have the same value, so "1.5" is equal to "1.50".
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d with all the digits of its scale and no exponent, such as "-1234.50".
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
+
+// MarshalJSON encodes d as a JSON string, such as "1.50", so that no precision is lost by decoders
+// that read JSON numbers as float64.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes a JSON string or number. Like the decoding of other types, null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// Scan implements sql.Scanner. It accepts the string, []byte, int64 and float64 values of database drivers,
+// so that it can read DECIMAL and NUMERIC columns. Scan into a **Decimal for nullable columns.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		return d.UnmarshalText(v)
+	case int64:
+		*d = NewDecimal(v, 0)
+		return nil
+	case float64:
+		f, err := NewDecimalFromFloat(v)
+		if err != nil {
+			return err
+		}
+		*d = f
+		return nil
+	default:
+		return fmt.Errorf("%w: cannot scan %T into a Decimal", ErrInvalidDecimal, src)
+	}
+}
+
+// Value implements driver.Valuer, it stores d as a string.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// align returns copies of the unscaled values of a and b at the larger scale of both.
+func align(a, b Decimal) (*big.Int, *big.Int, int) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+		return x, y, a.scale
+	default:
+		return x, y, a.scale
+	}
+}
+
+// pow10 returns 10^n for n >= 0.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
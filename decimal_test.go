@@ -0,0 +1,215 @@
+package goutil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      string
+		wantScale int
+		wantErr   bool
+	}{
+		{in: "123", want: "123", wantScale: 0},
+		{in: "-0.50", want: "-0.50", wantScale: 2},
+		{in: "+1.5", want: "1.5", wantScale: 1},
+		{in: ".25", want: "0.25", wantScale: 2},
+		{in: "7.", want: "7", wantScale: 0},
+		{in: "1.5e-3", want: "0.0015", wantScale: 4},
+		{in: "1.5E3", want: "1500", wantScale: 0},
+		{in: "-0", want: "0", wantScale: 0},
+		{in: "123456789012345678901234567890.123456789", want: "123456789012345678901234567890.123456789", wantScale: 9},
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1e", wantErr: true},
+		{in: "1e999999999", wantErr: true},
+		{in: " 1", wantErr: true},
+		{in: "0x10", wantErr: true},
+		{in: "NaN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidDecimal) {
+				t.Errorf("ParseDecimal(%q) error = %v, want %v", tt.in, err, ErrInvalidDecimal)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want || got.Scale() != tt.wantScale {
+			t.Errorf("ParseDecimal(%q) = %v (scale %d), %v, want %v (scale %d)", tt.in, got, got.Scale(), err, tt.want, tt.wantScale)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := MustParseDecimal
+
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{name: "add", got: d("0.1").Add(d("0.2")), want: "0.3"},
+		{name: "add scales", got: d("1.5").Add(d("2.25")), want: "3.75"},
+		{name: "sub", got: d("1.00").Sub(d("2.5")), want: "-1.50"},
+		{name: "mul", got: d("19.99").Mul(d("3")), want: "59.97"},
+		{name: "mul scales", got: d("1.5").Mul(d("-0.25")), want: "-0.375"},
+		{name: "neg", got: d("1.50").Neg(), want: "-1.50"},
+		{name: "abs", got: d("-1.50").Abs(), want: "1.50"},
+		{name: "zero value", got: Decimal{}.Add(d("2")), want: "2"},
+		{name: "round half even", got: d("2.345").Round(2, RoundHalfEven), want: "2.34"},
+		{name: "round half up", got: d("2.345").Round(2, RoundHalfUp), want: "2.35"},
+		{name: "round floor", got: d("-2.341").Round(2, RoundFloor), want: "-2.35"},
+		{name: "round extends", got: d("2.5").Round(3, RoundDown), want: "2.500"},
+		{name: "round tens", got: d("1250").Round(-2, RoundHalfEven), want: "1200"},
+		{name: "new", got: NewDecimal(150, 2), want: "1.50"},
+		{name: "new negative scale", got: NewDecimal(15, -2), want: "1500"},
+	}
+
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	d := MustParseDecimal
+
+	tests := []struct {
+		a, b  string
+		scale int
+		mode  RoundingMode
+		want  string
+	}{
+		{a: "10", b: "3", scale: 2, mode: RoundHalfEven, want: "3.33"},
+		{a: "20", b: "3", scale: 2, mode: RoundHalfEven, want: "6.67"},
+		{a: "20", b: "3", scale: 2, mode: RoundDown, want: "6.66"},
+		{a: "-1", b: "8", scale: 2, mode: RoundHalfEven, want: "-0.12"},
+		{a: "-1", b: "8", scale: 2, mode: RoundHalfUp, want: "-0.13"},
+		{a: "1", b: "-8", scale: 2, mode: RoundCeiling, want: "-0.12"},
+		{a: "0.001", b: "0.3", scale: 4, mode: RoundUp, want: "0.0034"},
+		{a: "100.00", b: "0.25", scale: 0, mode: RoundHalfEven, want: "400"},
+		{a: "12500", b: "10", scale: -2, mode: RoundHalfEven, want: "1200"},
+	}
+
+	for _, tt := range tests {
+		got, err := d(tt.a).Div(d(tt.b), tt.scale, tt.mode)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Decimal(%s).Div(%s, %d, %v) = %v, %v, want %v", tt.a, tt.b, tt.scale, tt.mode, got, err, tt.want)
+		}
+	}
+
+	if _, err := d("1").Div(d("0.00"), 2, RoundHalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Div() by zero error = %v, want %v", err, ErrDivisionByZero)
+	}
+}
+
+func TestDecimalCompare(t *testing.T) {
+	d := MustParseDecimal
+
+	if !d("1.5").Equal(d("1.50")) {
+		t.Error("Expected 1.5 to equal 1.50")
+	}
+	if got := d("-0.01").Cmp(d("0")); got != -1 {
+		t.Errorf("Cmp(-0.01, 0) = %d, want -1", got)
+	}
+	if got := d("10").Cmp(d("9.999")); got != 1 {
+		t.Errorf("Cmp(10, 9.999) = %d, want 1", got)
+	}
+	if !(Decimal{}).IsZero() || d("0.00").Sign() != 0 || d("-3").Sign() != -1 {
+		t.Error("Expected the zero value and 0.00 to be zero, and -3 to be negative")
+	}
+	if got := d("0.1").Float64(); got != 0.1 {
+		t.Errorf("Float64() = %v, want 0.1", got)
+	}
+}
+
+func TestNewDecimalFromFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{in: 0.1, want: "0.1"},
+		{in: -19.99, want: "-19.99"},
+		{in: 1e21, want: "1000000000000000000000"},
+		{in: 1.5e-7, want: "0.00000015"},
+	}
+
+	for _, tt := range tests {
+		got, err := NewDecimalFromFloat(tt.in)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("NewDecimalFromFloat(%v) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1)} {
+		if _, err := NewDecimalFromFloat(f); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("NewDecimalFromFloat(%v) error = %v, want %v", f, err, ErrInvalidDecimal)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type invoice struct {
+		Total Decimal  `json:"total"`
+		Tax   *Decimal `json:"tax"`
+	}
+
+	tax := MustParseDecimal("0.10")
+	data, err := json.Marshal(invoice{Total: MustParseDecimal("19.90"), Tax: &tax})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"total":"19.90","tax":"0.10"}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	var got invoice
+	if err := json.Unmarshal([]byte(`{"total": 19.90, "tax": null}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Total.String() != "19.90" || got.Tax != nil {
+		t.Errorf("json.Unmarshal() = %v, %v, want 19.90, nil", got.Total, got.Tax)
+	}
+
+	if err := json.Unmarshal([]byte(`{"total": "abc"}`), &got); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("json.Unmarshal() error = %v, want %v", err, ErrInvalidDecimal)
+	}
+}
+
+func TestDecimalSQL(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{src: "12.50", want: "12.50"},
+		{src: []byte("-0.001"), want: "-0.001"},
+		{src: int64(42), want: "42"},
+		{src: 0.25, want: "0.25"},
+		{src: nil, wantErr: true},
+		{src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var d Decimal
+		err := d.Scan(tt.src)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && d.String() != tt.want) {
+			t.Errorf("Scan(%v) = %v, %v, want %v", tt.src, d, err, tt.want)
+		}
+	}
+
+	v, err := MustParseDecimal("12.50").Value()
+	if err != nil || v != driver.Value("12.50") {
+		t.Errorf("Value() = %v, %v, want 12.50", v, err)
+	}
+}
@@ -3,3 +3,5 @@ module github.com/qcrao/goutil
 go 1.20
 
 require github.com/davecgh/go-spew v1.1.1
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,151 @@
+// Package goutiltest provides helpers for testing code built on goutil.
+package goutiltest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qcrao/goutil"
+)
+
+// FakeClock is a goutil.Clock whose time only moves when Advance is called.
+// Timers fire synchronously inside Advance, so a test controls exactly when waiting code wakes up.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns a Timer that fires once the fake time has advanced by d.
+func (c *FakeClock) NewTimer(d time.Duration) goutil.Timer {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schedule(t, d)
+
+	return t
+}
+
+// After returns the channel of a new Timer.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Advance moves the fake time forward by d and fires every timer that is due, earliest first.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Waiters returns the number of timers that have not fired or been stopped.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil blocks until at least n timers are waiting on the clock.
+// It lets a test wait for the code under test to reach its next wait before calling Advance.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// schedule sets the deadline of t and adds it to the waiting timers. c.mu must be held.
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		t.send(c.now)
+		return
+	}
+
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+// remove takes t out of the waiting timers and reports whether it was there. c.mu must be held.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// fire sends on every timer whose deadline has passed. c.mu must be held.
+func (c *FakeClock) fire() {
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+
+	i := 0
+	for ; i < len(c.timers) && !c.timers[i].deadline.After(c.now); i++ {
+		c.timers[i].send(c.timers[i].deadline)
+	}
+	c.timers = c.timers[i:]
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.clock.remove(t)
+	t.clock.schedule(t, d)
+
+	return active
+}
+
+// send delivers a tick without blocking, like the channel of a *time.Timer.
+func (t *fakeTimer) send(now time.Time) {
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
@@ -0,0 +1,90 @@
+package goutiltest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+
+	early := clock.NewTimer(time.Second)
+	late := clock.After(3 * time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-early.C():
+		t.Fatal("Timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case got := <-early.C():
+		if want := start.Add(time.Second); !got.Equal(want) {
+			t.Errorf("Expected tick at %v, got %v", want, got)
+		}
+	default:
+		t.Fatal("Expected timer to fire")
+	}
+
+	if clock.Waiters() != 1 {
+		t.Errorf("Expected 1 waiting timer, got %d", clock.Waiters())
+	}
+
+	clock.Advance(2 * time.Second)
+	select {
+	case <-late:
+	default:
+		t.Fatal("Expected After channel to fire")
+	}
+
+	if got, want := clock.Now(), start.Add(3500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestFakeClockStopAndReset(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+
+	timer := clock.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Error("Expected Stop to report an active timer")
+	}
+	if timer.Stop() {
+		t.Error("Expected second Stop to report an inactive timer")
+	}
+
+	if timer.Reset(2 * time.Second) {
+		t.Error("Expected Reset of a stopped timer to report an inactive timer")
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired before its reset deadline")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("Expected reset timer to fire")
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	fired := make(chan struct{})
+
+	go func() {
+		<-clock.After(time.Minute)
+		close(fired)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-fired
+}
@@ -0,0 +1,65 @@
+package goutiltest
+
+import "sync"
+
+// Metrics is a goutil.Metrics that keeps everything in memory, for tests to assert on.
+// Install it with goutil.SetMetrics.
+type Metrics struct {
+	mu         sync.Mutex
+	counters   map[metricKey]int64
+	histograms map[metricKey][]float64
+}
+
+type metricKey struct {
+	name, operation string
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters:   make(map[metricKey]int64),
+		histograms: make(map[metricKey][]float64),
+	}
+}
+
+// IncCounter adds delta to the counter name of operation.
+func (m *Metrics) IncCounter(name, operation string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[metricKey{name, operation}] += delta
+}
+
+// ObserveHistogram records value in the histogram name of operation.
+func (m *Metrics) ObserveHistogram(name, operation string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := metricKey{name, operation}
+	m.histograms[key] = append(m.histograms[key], value)
+}
+
+// Counter returns the value of the counter name of operation.
+func (m *Metrics) Counter(name, operation string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.counters[metricKey{name, operation}]
+}
+
+// Histogram returns a copy of the values observed in the histogram name of operation, in order.
+func (m *Metrics) Histogram(name, operation string) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]float64(nil), m.histograms[metricKey{name, operation}]...)
+}
+
+// Reset forgets every metric.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters = make(map[metricKey]int64)
+	m.histograms = make(map[metricKey][]float64)
+}
@@ -0,0 +1,28 @@
+package goutiltest
+
+import "testing"
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+
+	m.IncCounter("calls", "a", 1)
+	m.IncCounter("calls", "a", 2)
+	m.IncCounter("calls", "b", 1)
+	m.ObserveHistogram("latency", "a", 0.5)
+	m.ObserveHistogram("latency", "a", 1.5)
+
+	if got := m.Counter("calls", "a"); got != 3 {
+		t.Errorf("Counter(calls, a) = %d, want 3", got)
+	}
+	if got := m.Counter("calls", "c"); got != 0 {
+		t.Errorf("Counter(calls, c) = %d, want 0", got)
+	}
+	if got := m.Histogram("latency", "a"); len(got) != 2 || got[0] != 0.5 || got[1] != 1.5 {
+		t.Errorf("Histogram(latency, a) = %v, want [0.5 1.5]", got)
+	}
+
+	m.Reset()
+	if got := m.Counter("calls", "a"); got != 0 {
+		t.Errorf("Counter(calls, a) = %d after Reset, want 0", got)
+	}
+}
@@ -0,0 +1,170 @@
+package goutil
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// HedgeOption configures Hedge.
+type HedgeOption func(*hedgeOptions)
+
+type hedgeOptions struct {
+	clock Clock
+	stats *HedgeStats
+}
+
+// WithHedgeClock sets the Clock used to wait before hedging. It defaults to RealClock.
+func WithHedgeClock(clock Clock) HedgeOption {
+	return func(o *hedgeOptions) {
+		o.clock = clock
+	}
+}
+
+// WithHedgeStats records the outcome of the call in stats, which may be shared by many calls.
+func WithHedgeStats(stats *HedgeStats) HedgeOption {
+	return func(o *hedgeOptions) {
+		o.stats = stats
+	}
+}
+
+// HedgeStats counts the outcomes of hedged calls. It is safe for concurrent use.
+type HedgeStats struct {
+	calls  uint64
+	hedged uint64
+	wins   uint64
+}
+
+// Calls returns the number of calls to Hedge.
+func (s *HedgeStats) Calls() uint64 {
+	return atomic.LoadUint64(&s.calls)
+}
+
+// Hedged returns the number of calls that started at least one hedge.
+func (s *HedgeStats) Hedged() uint64 {
+	return atomic.LoadUint64(&s.hedged)
+}
+
+// Wins returns the number of calls whose result came from a hedge rather than the first attempt.
+func (s *HedgeStats) Wins() uint64 {
+	return atomic.LoadUint64(&s.wins)
+}
+
+// WinRate returns the fraction of hedged calls that were won by a hedge.
+func (s *HedgeStats) WinRate() float64 {
+	hedged := s.Hedged()
+	if hedged == 0 {
+		return 0
+	}
+
+	return float64(s.Wins()) / float64(hedged)
+}
+
+type hedgeResult[T any] struct {
+	value   T
+	err     error
+	attempt int
+}
+
+// Hedge calls fn and, if it has not succeeded after delay, calls it again, up to maxAttempts concurrent calls.
+// A failed call starts the next one right away. The first successful result is returned
+// and the context passed to the other calls is cancelled.
+// Each call runs in its own goroutine with recovery capability, a panic counts as a failed call.
+// If every call fails, Hedge returns the error of the last one to finish, and if ctx is done first, ctx.Err().
+func Hedge[T any](ctx context.Context, delay time.Duration, maxAttempts int, fn func(context.Context) (T, error), opts ...HedgeOption) (T, error) {
+	o := &hedgeOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The calls still running when Hedge returns see ctx cancelled and drop their results, so the channel
+	// needs no room for them and maxAttempts may be arbitrarily large.
+	results := make(chan hedgeResult[T])
+	send := func(r hedgeResult[T]) {
+		select {
+		case results <- r:
+		case <-ctx.Done():
+		}
+	}
+	launched := 0
+	launch := func() {
+		attempt := launched
+		launched++
+
+		GoWithErrorHandler(func() {
+			value, err := fn(ctx)
+			send(hedgeResult[T]{value: value, err: err, attempt: attempt})
+		}, func(err interface{}) {
+			send(hedgeResult[T]{err: fmt.Errorf("goutil: hedged call panicked: %v", err), attempt: attempt})
+		})
+	}
+
+	finish := func(winner int) {
+		if o.stats == nil {
+			return
+		}
+
+		atomic.AddUint64(&o.stats.calls, 1)
+		if launched > 1 {
+			atomic.AddUint64(&o.stats.hedged, 1)
+		}
+		if winner > 0 {
+			atomic.AddUint64(&o.stats.wins, 1)
+		}
+	}
+
+	launch()
+	timer := o.clock.NewTimer(delay)
+	defer timer.Stop()
+
+	var zero T
+	pending := 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				finish(r.attempt)
+				return r.value, nil
+			}
+
+			if launched < maxAttempts {
+				launch()
+				pending++
+				resetTimer(timer, delay)
+			} else if pending == 0 {
+				finish(-1)
+				return zero, r.err
+			}
+		case <-timer.C():
+			if launched < maxAttempts {
+				launch()
+				pending++
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			finish(-1)
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// resetTimer stops t, drains a pending tick and restarts it with d.
+func resetTimer(t Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
+		}
+	}
+
+	t.Reset(d)
+}
@@ -0,0 +1,112 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgeFirstAttemptWins(t *testing.T) {
+	stats := &HedgeStats{}
+	var calls int32
+
+	got, err := Hedge(context.Background(), time.Hour, 3, func(context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fast", nil
+	}, WithHedgeStats(stats))
+
+	if err != nil || got != "fast" {
+		t.Fatalf("Hedge() = %q, %v, want fast, nil", got, err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected a single call, got %d", calls)
+	}
+	if stats.Calls() != 1 || stats.Hedged() != 0 || stats.Wins() != 0 {
+		t.Errorf("Unexpected stats: %d calls, %d hedged, %d wins", stats.Calls(), stats.Hedged(), stats.Wins())
+	}
+}
+
+func TestHedgeSlowAttemptIsHedged(t *testing.T) {
+	stats := &HedgeStats{}
+	var calls int32
+	cancelled := make(chan struct{})
+
+	got, err := Hedge(context.Background(), 10*time.Millisecond, 2, func(ctx context.Context) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			close(cancelled)
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	}, WithHedgeStats(stats))
+
+	if err != nil || got != 2 {
+		t.Fatalf("Hedge() = %d, %v, want 2, nil", got, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the slow attempt to be cancelled")
+	}
+
+	if stats.Hedged() != 1 || stats.Wins() != 1 || stats.WinRate() != 1 {
+		t.Errorf("Unexpected stats: %d hedged, %d wins, %v win rate", stats.Hedged(), stats.Wins(), stats.WinRate())
+	}
+}
+
+func TestHedgeAllAttemptsFail(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	var calls int32
+
+	_, err := Hedge(context.Background(), time.Hour, 3, func(context.Context) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			panic("boom")
+		}
+		return 0, ErrCustom
+	})
+
+	if err == nil {
+		t.Fatal("Expected an error when every attempt fails")
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("Expected failures to start the next attempt right away, got %d calls", calls)
+	}
+}
+
+func TestHedgeContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Hedge(ctx, time.Millisecond, 2, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return 0, ctx.Err()
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Hedge() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestHedgeUnboundedAttempts(t *testing.T) {
+	var ErrBusy = errors.New("busy")
+	var calls int32
+
+	got, err := Hedge(context.Background(), time.Hour, math.MaxInt, func(context.Context) (int, error) {
+		if n := atomic.AddInt32(&calls, 1); n < 100 {
+			return 0, ErrBusy
+		}
+		return 100, nil
+	})
+
+	if err != nil || got != 100 {
+		t.Fatalf("Hedge() = %d, %v, want 100, nil", got, err)
+	}
+	if atomic.LoadInt32(&calls) != 100 {
+		t.Errorf("Expected 100 calls, got %d", calls)
+	}
+}
@@ -10,7 +10,7 @@ const defaultPrecision = 8
 // It takes two float64 numbers f1 and f2, and an optional uint8 precision.
 // If precision is not provided, it defaults to defaultPrecision.
 // The function returns true if the absolute difference between f1 and f2 is less than or equal to the threshold (10 to the power of -precision), indicating that they are equal to the specified number of decimal places.
-// It returns false otherwise.
+// It returns false otherwise. For values far from 1, see Float64EqualRel, Float64EqualULP and Float64IsClose.
 func Float64Equal(f1 float64, f2 float64, optionalPrecision ...uint8) bool {
 	var actualPrecision uint8
 	if len(optionalPrecision) == 0 {
@@ -23,3 +23,98 @@ func Float64Equal(f1 float64, f2 float64, optionalPrecision ...uint8) bool {
 
 	return math.Abs(f1-f2) <= threshold
 }
+
+// Float64EqualRel reports whether a and b differ by at most relTol times the larger of their magnitudes,
+// so that the tolerance scales with the numbers compared: Float64EqualRel(1e20, 1e20+1e4, 1e-9) is true.
+// NaN is not equal to anything, an infinity is only equal to itself, and 0 is equal to -0.
+// Near zero a relative tolerance is very strict, see Float64IsClose to combine it with an absolute one.
+func Float64EqualRel(a, b, relTol float64) bool {
+	return Float64IsClose(a, b, relTol, 0)
+}
+
+// Float64IsClose reports whether a and b are close like Python's math.isclose:
+// |a-b| <= max(relTol * max(|a|, |b|), absTol).
+// NaN is not close to anything, an infinity is only close to itself, and 0 is equal to -0.
+func Float64IsClose(a, b, relTol, absTol float64) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+
+	diff := math.Abs(a - b)
+
+	return diff <= relTol*math.Max(math.Abs(a), math.Abs(b)) || diff <= absTol
+}
+
+// Float64EqualULP reports whether a and b are at most maxULP representable float64 values apart.
+// Float64EqualULP(a, b, 0) is a == b, 1 also accepts the next float64 in either direction.
+// NaN is not equal to anything, an infinity is only equal to itself, and 0 is equal to -0.
+func Float64EqualULP(a, b float64, maxULP uint64) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+
+	return ulpDistance(orderedFloat64Bits(a), orderedFloat64Bits(b)) <= maxULP
+}
+
+// Float32EqualRel is Float64EqualRel for float32.
+func Float32EqualRel(a, b, relTol float32) bool {
+	return Float32IsClose(a, b, relTol, 0)
+}
+
+// Float32IsClose is Float64IsClose for float32.
+func Float32IsClose(a, b, relTol, absTol float32) bool {
+	// The difference is computed in float64, so it cannot overflow or be rounded to float32 first.
+	return Float64IsClose(float64(a), float64(b), float64(relTol), float64(absTol))
+}
+
+// Float32EqualULP is Float64EqualULP for float32, counting representable float32 values.
+func Float32EqualULP(a, b float32, maxULP uint32) bool {
+	if a == b {
+		return true
+	}
+	if isNaN32(a) || isNaN32(b) || math.IsInf(float64(a), 0) || math.IsInf(float64(b), 0) {
+		return false
+	}
+
+	return ulpDistance(orderedFloat32Bits(a), orderedFloat32Bits(b)) <= uint64(maxULP)
+}
+
+// orderedFloat64Bits maps f to an integer such that consecutive float64 values map to consecutive integers,
+// with 0 and -0 both mapping to 0.
+func orderedFloat64Bits(f float64) int64 {
+	i := int64(math.Float64bits(f))
+	if i < 0 {
+		i = math.MinInt64 - i
+	}
+
+	return i
+}
+
+// orderedFloat32Bits is orderedFloat64Bits for float32.
+func orderedFloat32Bits(f float32) int64 {
+	i := int64(int32(math.Float32bits(f)))
+	if i < 0 {
+		i = math.MinInt32 - i
+	}
+
+	return i
+}
+
+// ulpDistance returns |a-b| without overflowing.
+func ulpDistance(a, b int64) uint64 {
+	if a > b {
+		return uint64(a) - uint64(b)
+	}
+
+	return uint64(b) - uint64(a)
+}
+
+func isNaN32(f float32) bool {
+	return f != f
+}
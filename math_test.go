@@ -1,6 +1,9 @@
 package goutil
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestFloat64Equal(t *testing.T) {
 	tests := []struct {
@@ -34,3 +37,129 @@ func TestFloat64Equal(t *testing.T) {
 		}
 	}
 }
+
+func TestFloat64IsClose(t *testing.T) {
+	inf, nan := math.Inf(1), math.NaN()
+
+	tests := []struct {
+		a, b           float64
+		relTol, absTol float64
+		want           bool
+	}{
+		{1e20, 1e20 + 1e4, 1e-9, 0, true},
+		{1e20, 1.1e20, 1e-9, 0, false},
+		{1e-20, 2e-20, 1e-9, 0, false},
+		{1e-20, 2e-20, 1e-9, 1e-12, true},
+		{0, 1e-300, 1e-9, 0, false},
+		{0, math.Copysign(0, -1), 0, 0, true},
+		{1, 1.0000000001, 0, 0, false},
+		{nan, nan, 1, 1, false},
+		{nan, 1, 1, 1, false},
+		{inf, inf, 0, 0, true},
+		{-inf, -inf, 0, 0, true},
+		{inf, -inf, 1, 1, false},
+		{inf, math.MaxFloat64, 1, 1, false},
+		{-1, 1, 1, 0, false},
+		{-1, -1.0000000001, 1e-9, 0, true},
+	}
+
+	for _, tt := range tests {
+		if got := Float64IsClose(tt.a, tt.b, tt.relTol, tt.absTol); got != tt.want {
+			t.Errorf("Float64IsClose(%v, %v, %v, %v) = %v, want %v", tt.a, tt.b, tt.relTol, tt.absTol, got, tt.want)
+		}
+		if got := Float64IsClose(tt.b, tt.a, tt.relTol, tt.absTol); got != tt.want {
+			t.Errorf("Float64IsClose(%v, %v, %v, %v) = %v, want %v", tt.b, tt.a, tt.relTol, tt.absTol, got, tt.want)
+		}
+		if tt.absTol == 0 {
+			if got := Float64EqualRel(tt.a, tt.b, tt.relTol); got != tt.want {
+				t.Errorf("Float64EqualRel(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.relTol, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestFloat64EqualULP(t *testing.T) {
+	one := 1.0
+	next := math.Nextafter(one, 2)
+	smallest := math.SmallestNonzeroFloat64
+
+	tests := []struct {
+		a, b   float64
+		maxULP uint64
+		want   bool
+	}{
+		{one, one, 0, true},
+		{one, next, 0, false},
+		{one, next, 1, true},
+		{one, math.Nextafter(next, 2), 1, false},
+		{0.1 + 0.2, 0.3, 1, true},
+		{0, math.Copysign(0, -1), 0, true},
+		{smallest, -smallest, 1, false},
+		{smallest, -smallest, 2, true},
+		{-one, math.Nextafter(-one, -2), 1, true},
+		{1e20, 1e20 + 1e4, 1, true},
+		{math.MaxFloat64, math.Inf(1), math.MaxUint64, false},
+		{math.Inf(-1), math.Inf(-1), 0, true},
+		{math.NaN(), math.NaN(), math.MaxUint64, false},
+		{-math.MaxFloat64, math.MaxFloat64, math.MaxUint64, true},
+	}
+
+	for _, tt := range tests {
+		if got := Float64EqualULP(tt.a, tt.b, tt.maxULP); got != tt.want {
+			t.Errorf("Float64EqualULP(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.maxULP, got, tt.want)
+		}
+		if got := Float64EqualULP(tt.b, tt.a, tt.maxULP); got != tt.want {
+			t.Errorf("Float64EqualULP(%v, %v, %v) = %v, want %v", tt.b, tt.a, tt.maxULP, got, tt.want)
+		}
+	}
+}
+
+func TestFloat32Equal(t *testing.T) {
+	one := float32(1)
+	next := math.Nextafter32(one, 2)
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+
+	ulpTests := []struct {
+		a, b   float32
+		maxULP uint32
+		want   bool
+	}{
+		{one, next, 0, false},
+		{one, next, 1, true},
+		{float32(0.1) + float32(0.2), float32(0.3), 1, true},
+		{0, float32(math.Copysign(0, -1)), 0, true},
+		{math.SmallestNonzeroFloat32, -math.SmallestNonzeroFloat32, 2, true},
+		{math.MaxFloat32, inf, math.MaxUint32, false},
+		{nan, nan, math.MaxUint32, false},
+	}
+
+	for _, tt := range ulpTests {
+		if got := Float32EqualULP(tt.a, tt.b, tt.maxULP); got != tt.want {
+			t.Errorf("Float32EqualULP(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.maxULP, got, tt.want)
+		}
+	}
+
+	closeTests := []struct {
+		a, b           float32
+		relTol, absTol float32
+		want           bool
+	}{
+		{1e20, 1.0000001e20, 1e-6, 0, true},
+		{1e-20, 2e-20, 1e-6, 0, false},
+		{1e-20, 2e-20, 1e-6, 1e-12, true},
+		{inf, inf, 0, 0, true},
+		{nan, 1, 1, 1, false},
+	}
+
+	for _, tt := range closeTests {
+		if got := Float32IsClose(tt.a, tt.b, tt.relTol, tt.absTol); got != tt.want {
+			t.Errorf("Float32IsClose(%v, %v, %v, %v) = %v, want %v", tt.a, tt.b, tt.relTol, tt.absTol, got, tt.want)
+		}
+		if tt.absTol == 0 {
+			if got := Float32EqualRel(tt.a, tt.b, tt.relTol); got != tt.want {
+				t.Errorf("Float32EqualRel(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.relTol, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package goutil
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Names of the metrics reported by the retry helpers, BatchProcess and the recovery of Go.
+// Durations are observed in seconds.
+const (
+	MetricRetryAttempts     = "retry_attempts"
+	MetricRetrySuccesses    = "retry_successes"
+	MetricRetryGiveUps      = "retry_give_ups"
+	MetricRetryDelaySeconds = "retry_delay_seconds"
+
+	MetricBatches              = "batches"
+	MetricBatchErrors          = "batch_errors"
+	MetricBatchDurationSeconds = "batch_duration_seconds"
+
+	MetricPanicsRecovered = "panics_recovered"
+)
+
+// UnknownOperation is the operation of metrics reported without an operation name in the context.
+const UnknownOperation = "unknown"
+
+// Metrics receives the counters and histograms reported by goutil, keyed by metric name and operation.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	IncCounter(name, operation string, delta int64)
+	ObserveHistogram(name, operation string, value float64)
+}
+
+type metricsHolder struct {
+	m Metrics
+}
+
+var (
+	metrics            atomic.Pointer[metricsHolder]
+	defaultMetricsOnce sync.Once
+	defaultMetrics     *ExpvarMetrics
+)
+
+// SetMetrics sets the Metrics that goutil reports into. SetMetrics(nil) turns reporting off.
+// By default, metrics are published with expvar under the name "goutil", see ExpvarMetrics.
+// If the application already published a *expvar.Map under that name, the metrics are added to it,
+// and if it published another kind of variable, the default metrics are kept but not published.
+func SetMetrics(m Metrics) {
+	metrics.Store(&metricsHolder{m: m})
+}
+
+// CurrentMetrics returns the Metrics that goutil reports into: the one set with SetMetrics, or the expvar default.
+// It returns nil if reporting is off.
+func CurrentMetrics() Metrics {
+	if h := metrics.Load(); h != nil {
+		return h.m
+	}
+
+	defaultMetricsOnce.Do(func() {
+		var root *expvar.Map
+		switch v := expvar.Get("goutil").(type) {
+		case nil:
+			root = expvar.NewMap("goutil")
+		case *expvar.Map:
+			root = v
+		default:
+			root = new(expvar.Map).Init()
+		}
+		defaultMetrics = NewExpvarMetricsFromMap(root)
+	})
+
+	return defaultMetrics
+}
+
+type operationKey struct{}
+
+// ContextWithOperation returns a copy of ctx naming the operation that the metrics reported under ctx belong to,
+// such as "payments.charge".
+func ContextWithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the operation named by ContextWithOperation, or UnknownOperation.
+func OperationFromContext(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+
+	return UnknownOperation
+}
+
+// ExpvarMetrics publishes metrics with expvar, as a map of metric names to maps of operations.
+// A counter is an integer. A histogram is an object with the "count" and "sum" of the observed values
+// and the estimated quantiles "p50", "p90", "p99" and "p999", within 1% of the true values, see QuantileSketch.
+type ExpvarMetrics struct {
+	root *expvar.Map
+	mu   sync.Mutex
+}
+
+// NewExpvarMetrics returns an ExpvarMetrics publishing under name. If a *expvar.Map is already published under name,
+// the metrics are added to it. NewExpvarMetrics panics if name is in use by another kind of variable.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return NewExpvarMetricsFromMap(expvar.NewMap(name))
+	case *expvar.Map:
+		return NewExpvarMetricsFromMap(v)
+	default:
+		panic(fmt.Sprintf("goutil: expvar %q is a %T, not a *expvar.Map", name, v))
+	}
+}
+
+// NewExpvarMetricsFromMap returns an ExpvarMetrics adding the metrics to root, which the caller may publish
+// or nest in another map.
+func NewExpvarMetricsFromMap(root *expvar.Map) *ExpvarMetrics {
+	return &ExpvarMetrics{root: root}
+}
+
+// IncCounter adds delta to the counter name of operation.
+func (m *ExpvarMetrics) IncCounter(name, operation string, delta int64) {
+	m.metric(name).Add(operation, delta)
+}
+
+// ObserveHistogram adds value to the histogram name of operation. NaN and infinite values are ignored.
+func (m *ExpvarMetrics) ObserveHistogram(name, operation string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+
+	m.mu.Lock()
+	ops := m.metricLocked(name)
+	h, ok := ops.Get(operation).(*expvarHistogram)
+	if !ok {
+		h = &expvarHistogram{sketch: NewQuantileSketch(0.01, 0)}
+		ops.Set(operation, h)
+	}
+	m.mu.Unlock()
+
+	h.observe(value)
+}
+
+// metric returns the map of operations of the metric name, creating it if needed.
+func (m *ExpvarMetrics) metric(name string) *expvar.Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.metricLocked(name)
+}
+
+func (m *ExpvarMetrics) metricLocked(name string) *expvar.Map {
+	ops, ok := m.root.Get(name).(*expvar.Map)
+	if !ok {
+		ops = new(expvar.Map).Init()
+		m.root.Set(name, ops)
+	}
+
+	return ops
+}
+
+// expvarHistogram is the expvar.Var of a histogram of ExpvarMetrics.
+type expvarHistogram struct {
+	mu     sync.Mutex
+	sketch *QuantileSketch
+	sum    float64
+}
+
+func (h *expvarHistogram) observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.sketch.Add(value)
+	h.sum += value
+}
+
+// expvarQuantiles are the quantiles published for every histogram, by name.
+var expvarQuantiles = []struct {
+	name string
+	q    float64
+}{{"p50", 0.5}, {"p90", 0.9}, {"p99", 0.99}, {"p999", 0.999}}
+
+// String returns the histogram as a JSON object, as required by expvar.Var.
+func (h *expvarHistogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"count": %d, "sum": %s`, h.sketch.Count(), formatJSONFloat(h.sum))
+	for _, q := range expvarQuantiles {
+		fmt.Fprintf(&b, `, %q: %s`, q.name, formatJSONFloat(h.sketch.Quantile(q.q)))
+	}
+	b.WriteString("}")
+
+	return b.String()
+}
+
+// formatJSONFloat formats f as a JSON number, or null if JSON cannot represent it.
+func formatJSONFloat(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "null"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// funcName returns the name of the function fn, to use as the operation of its metrics.
+func funcName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+
+	return UnknownOperation
+}
+
+// metricsReporter reports the metrics of one operation into the current Metrics, if any.
+type metricsReporter struct {
+	m         Metrics
+	operation string
+}
+
+func newMetricsReporter(operation string) metricsReporter {
+	return metricsReporter{m: CurrentMetrics(), operation: operation}
+}
+
+func (r metricsReporter) inc(name string) {
+	if r.m != nil {
+		r.m.IncCounter(name, r.operation, 1)
+	}
+}
+
+func (r metricsReporter) observe(name string, value float64) {
+	if r.m != nil {
+		r.m.ObserveHistogram(name, r.operation, value)
+	}
+}
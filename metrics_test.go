@@ -0,0 +1,201 @@
+package goutil_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qcrao/goutil"
+	"github.com/qcrao/goutil/goutiltest"
+)
+
+// useMetrics installs a fresh in-memory Metrics for the duration of the test.
+func useMetrics(t *testing.T) *goutiltest.Metrics {
+	prev := goutil.CurrentMetrics()
+	t.Cleanup(func() { goutil.SetMetrics(prev) })
+
+	m := goutiltest.NewMetrics()
+	goutil.SetMetrics(m)
+
+	return m
+}
+
+func TestRetryMetrics(t *testing.T) {
+	m := useMetrics(t)
+	ctx := goutil.ContextWithOperation(context.Background(), "payments")
+	backoff := goutil.MaxRetries(goutil.ConstantBackoff{Delay: time.Millisecond}, 2)
+
+	calls := 0
+	err := goutil.RetryWithContext(ctx, backoff, func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = goutil.RetryWithContext(ctx, backoff, func(context.Context) (bool, error) {
+		return false, errors.New("always")
+	})
+
+	if got := m.Counter(goutil.MetricRetryAttempts, "payments"); got != 6 {
+		t.Errorf("Expected 6 attempts, got %d", got)
+	}
+	if got := m.Counter(goutil.MetricRetrySuccesses, "payments"); got != 1 {
+		t.Errorf("Expected 1 success, got %d", got)
+	}
+	if got := m.Counter(goutil.MetricRetryGiveUps, "payments"); got != 1 {
+		t.Errorf("Expected 1 give up, got %d", got)
+	}
+	if got := m.Histogram(goutil.MetricRetryDelaySeconds, "payments"); len(got) != 4 || got[0] != 0.001 {
+		t.Errorf("Expected 4 delays of 1ms, got %v", got)
+	}
+
+	_ = goutil.RetryWithContext(context.Background(), goutil.NewNoRetry(), func(context.Context) (bool, error) {
+		return true, nil
+	})
+	if got := m.Counter(goutil.MetricRetryAttempts, goutil.UnknownOperation); got != 1 {
+		t.Errorf("Expected the attempt of an unnamed operation to be reported as %q, got %d", goutil.UnknownOperation, got)
+	}
+}
+
+type sliceProcessor struct {
+	goutil.Int64Split
+	fail bool
+}
+
+type countResult struct {
+	n int
+}
+
+func (r *countResult) Merge(other goutil.BatchResult) {
+	r.n += other.(*countResult).n
+}
+
+func (p *sliceProcessor) Process(ctx context.Context, batch interface{}) (goutil.BatchResult, error) {
+	if p.fail {
+		return nil, errors.New("batch failed")
+	}
+
+	return &countResult{n: len(batch.([]int64))}, nil
+}
+
+func TestBatchMetrics(t *testing.T) {
+	m := useMetrics(t)
+	ctx := goutil.ContextWithOperation(context.Background(), "import")
+	items := []int64{1, 2, 3, 4, 5}
+
+	if _, err := goutil.BatchProcess(ctx, items, 2, 2, &sliceProcessor{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := goutil.BatchProcess(ctx, items, 5, 1, &sliceProcessor{fail: true}); err == nil {
+		t.Fatal("Expected the failing batch to return an error")
+	}
+
+	if got := m.Counter(goutil.MetricBatches, "import"); got != 4 {
+		t.Errorf("Expected 4 batches, got %d", got)
+	}
+	if got := m.Counter(goutil.MetricBatchErrors, "import"); got != 1 {
+		t.Errorf("Expected 1 batch error, got %d", got)
+	}
+	if got := m.Histogram(goutil.MetricBatchDurationSeconds, "import"); len(got) != 4 {
+		t.Errorf("Expected 4 batch durations, got %v", got)
+	}
+}
+
+func panicky() {
+	panic("boom")
+}
+
+func TestRecoveryMetrics(t *testing.T) {
+	m := useMetrics(t)
+	recovered := make(chan struct{})
+
+	goutil.GoWithErrorHandler(panicky, func(interface{}) { close(recovered) })
+	<-recovered
+
+	if got := m.Counter(goutil.MetricPanicsRecovered, "github.com/qcrao/goutil_test.panicky"); got != 1 {
+		t.Errorf("Expected 1 recovered panic for panicky, got %d", got)
+	}
+}
+
+func TestSetMetricsNil(t *testing.T) {
+	useMetrics(t)
+	goutil.SetMetrics(nil)
+
+	if goutil.CurrentMetrics() != nil {
+		t.Fatal("Expected SetMetrics(nil) to turn reporting off")
+	}
+	if err := goutil.RetryWithContext(context.Background(), goutil.NewNoRetry(), func(context.Context) (bool, error) {
+		return true, nil
+	}); err != nil {
+		t.Errorf("RetryWithContext() error = %v without metrics, want nil", err)
+	}
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	// expvar names cannot be published twice, so every run of the test uses its own.
+	name := fmt.Sprintf("goutil_test_metrics_%d", time.Now().UnixNano())
+	m := goutil.NewExpvarMetrics(name)
+	m.IncCounter("calls", "a", 2)
+	for i := 1; i <= 100; i++ {
+		m.ObserveHistogram("latency", "a", float64(i)/100)
+	}
+
+	var got struct {
+		Calls   map[string]int64 `json:"calls"`
+		Latency map[string]struct {
+			Count int64   `json:"count"`
+			Sum   float64 `json:"sum"`
+			P50   float64 `json:"p50"`
+			P99   float64 `json:"p99"`
+		} `json:"latency"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	latency := got.Latency["a"]
+	if got.Calls["a"] != 2 || latency.Count != 100 || math.Abs(latency.Sum-50.5) > 1e-9 {
+		t.Errorf("Expected calls 2 and latency count 100 sum 50.5, got %+v", got)
+	}
+	if math.Abs(latency.P50-0.5) > 0.01 || math.Abs(latency.P99-0.99) > 0.02 {
+		t.Errorf("Expected latency p50 about 0.5 and p99 about 0.99, got %+v", latency)
+	}
+
+	// A second ExpvarMetrics under the same name adds to the published map instead of panicking.
+	goutil.NewExpvarMetrics(name).IncCounter("calls", "a", 1)
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Calls["a"] != 3 {
+		t.Errorf("Expected calls 3 after reusing the map, got %d", got.Calls["a"])
+	}
+}
+
+func TestExpvarMetricsNameInUse(t *testing.T) {
+	name := fmt.Sprintf("goutil_test_string_%d", time.Now().UnixNano())
+	expvar.NewString(name)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected NewExpvarMetrics to panic for a name used by another kind of variable")
+		}
+	}()
+	goutil.NewExpvarMetrics(name)
+}
+
+func TestExpvarMetricsFromMap(t *testing.T) {
+	root := new(expvar.Map).Init()
+	goutil.NewExpvarMetricsFromMap(root).IncCounter("calls", "a", 1)
+
+	calls, ok := root.Get("calls").(*expvar.Map)
+	if !ok || calls.Get("a").String() != "1" {
+		t.Errorf("Expected calls of a to be 1 in the given map, got %v", root)
+	}
+}
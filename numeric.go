@@ -0,0 +1,269 @@
+package goutil
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Signed is a constraint for the signed integer types.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint for the unsigned integer types.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer is a constraint for the integer types.
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float is a constraint for the floating-point types.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is a constraint for the integer and floating-point types.
+type Number interface {
+	Integer | Float
+}
+
+// Clamp returns v limited to the range [lo, hi], which must not be empty. A NaN v is returned unchanged.
+func Clamp[T Number](v, lo, hi T) T {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+
+	return v
+}
+
+// Abs returns the absolute value of v. Like negation, it overflows for the smallest value of a signed type,
+// which it returns unchanged.
+func Abs[T Signed | Float](v T) T {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+// MinOf returns the smallest of values, or false if there are none.
+// For floating-point types, the result is NaN if any value is NaN.
+func MinOf[T Number](values ...T) (T, bool) {
+	return extremum(values, func(a, b T) bool { return a < b })
+}
+
+// MaxOf returns the largest of values, or false if there are none.
+// For floating-point types, the result is NaN if any value is NaN.
+func MaxOf[T Number](values ...T) (T, bool) {
+	return extremum(values, func(a, b T) bool { return a > b })
+}
+
+func extremum[T Number](values []T, better func(a, b T) bool) (T, bool) {
+	if len(values) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	result := values[0]
+	for _, v := range values {
+		if v != v {
+			return v, true
+		}
+		if better(v, result) {
+			result = v
+		}
+	}
+
+	return result, true
+}
+
+// Sum returns the sum of values, 0 if there are none. Integer sums wrap around on overflow, see CheckedAdd.
+func Sum[T Number](values ...T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum
+}
+
+// Mean returns the arithmetic mean of values as a float64, or NaN if there are none.
+// It is computed in float64, so the sum of integers does not overflow.
+func Mean[T Number](values ...T) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+
+	return sum / float64(len(values))
+}
+
+// CheckedAdd returns a + b, and false if the sum overflows T.
+func CheckedAdd[T Integer](a, b T) (T, bool) {
+	c := a + b
+	if isSigned[T]() {
+		return c, (c > a) == (b > 0)
+	}
+
+	return c, c >= a
+}
+
+// CheckedSub returns a - b, and false if the difference overflows T.
+func CheckedSub[T Integer](a, b T) (T, bool) {
+	c := a - b
+	if isSigned[T]() {
+		return c, (c < a) == (b > 0)
+	}
+
+	return c, b <= a
+}
+
+// CheckedMul returns a * b, and false if the product overflows T.
+func CheckedMul[T Integer](a, b T) (T, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	c := a * b
+	if isSigned[T]() {
+		lo, _ := integerRange[T]()
+		if (a == lo && b == ^T(0)) || (b == lo && a == ^T(0)) {
+			return c, false
+		}
+	}
+
+	return c, c/b == a
+}
+
+// SaturatingAdd returns a + b, limited to the range of T instead of overflowing.
+func SaturatingAdd[T Integer](a, b T) T {
+	c, ok := CheckedAdd(a, b)
+	if ok {
+		return c
+	}
+
+	lo, hi := integerRange[T]()
+	if b > 0 {
+		return hi
+	}
+
+	return lo
+}
+
+// SaturatingSub returns a - b, limited to the range of T instead of overflowing.
+func SaturatingSub[T Integer](a, b T) T {
+	c, ok := CheckedSub(a, b)
+	if ok {
+		return c
+	}
+
+	lo, hi := integerRange[T]()
+	if b > 0 {
+		return lo
+	}
+
+	return hi
+}
+
+// SaturatingMul returns a * b, limited to the range of T instead of overflowing.
+func SaturatingMul[T Integer](a, b T) T {
+	c, ok := CheckedMul(a, b)
+	if ok {
+		return c
+	}
+
+	lo, hi := integerRange[T]()
+	if (a < 0) != (b < 0) {
+		return lo
+	}
+
+	return hi
+}
+
+// DivRound returns a / b rounded with mode, where the / operator truncates toward zero.
+// It returns false if b is 0 or the quotient overflows T, which only happens when dividing
+// the smallest value of a signed type by -1.
+func DivRound[T Integer](a, b T, mode RoundingMode) (T, bool) {
+	if b == 0 {
+		return 0, false
+	}
+
+	signed := isSigned[T]()
+	if lo, _ := integerRange[T](); signed && a == lo && b == ^T(0) {
+		return 0, false
+	}
+
+	q, r := a/b, a%b
+	if r == 0 {
+		return q, true
+	}
+
+	sign := 1
+	if signed && (a < 0) != (b < 0) {
+		sign = -1
+	}
+
+	// half compares 2|r| with |b| as |r| with |b|-|r|, which cannot overflow.
+	var half int
+	if signed {
+		// Work with non-positive values, which cover the whole range of T.
+		nr, nb := r, b
+		if nr > 0 {
+			nr = -nr
+		}
+		if nb > 0 {
+			nb = -nb
+		}
+		half = compare(nb-nr, nr)
+	} else {
+		half = compare(r, b-r)
+	}
+
+	if mode.roundUp(sign, q%2 != 0, half) {
+		if sign > 0 {
+			q++
+		} else {
+			q--
+		}
+	}
+
+	return q, true
+}
+
+func compare[T Integer](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isSigned[T Integer]() bool {
+	return ^T(0) < 0
+}
+
+// integerRange returns the smallest and largest values of T.
+func integerRange[T Integer]() (lo, hi T) {
+	if !isSigned[T]() {
+		return 0, ^T(0)
+	}
+
+	var zero T
+	bits := unsafe.Sizeof(zero) * 8
+	lo = T(1) << (bits - 1)
+
+	return lo, lo - 1
+}
@@ -0,0 +1,189 @@
+package goutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClampAbs(t *testing.T) {
+	if got := Clamp(15, 0, 10); got != 10 {
+		t.Errorf("Clamp(15, 0, 10) = %v, want 10", got)
+	}
+	if got := Clamp(-0.5, 0, 1); got != 0 {
+		t.Errorf("Clamp(-0.5, 0, 1) = %v, want 0", got)
+	}
+	if got := Clamp(uint8(7), 1, 9); got != 7 {
+		t.Errorf("Clamp(7, 1, 9) = %v, want 7", got)
+	}
+	if got := Clamp(math.NaN(), 0, 1); !math.IsNaN(got) {
+		t.Errorf("Clamp(NaN, 0, 1) = %v, want NaN", got)
+	}
+
+	if got := Abs(-3); got != 3 {
+		t.Errorf("Abs(-3) = %v, want 3", got)
+	}
+	if got := Abs(math.Copysign(0, -1)); got != 0 {
+		t.Errorf("Abs(-0) = %v, want 0", got)
+	}
+	if got := Abs(int8(math.MinInt8)); got != math.MinInt8 {
+		t.Errorf("Abs(MinInt8) = %v, want %v", got, math.MinInt8)
+	}
+}
+
+func TestMinMaxSumMean(t *testing.T) {
+	if got, ok := MinOf(3, -1, 2); got != -1 || !ok {
+		t.Errorf("MinOf(3, -1, 2) = %v, %v, want -1, true", got, ok)
+	}
+	if got, ok := MaxOf(1.5, 2.5, -3); got != 2.5 || !ok {
+		t.Errorf("MaxOf(1.5, 2.5, -3) = %v, %v, want 2.5, true", got, ok)
+	}
+	if _, ok := MaxOf[int](); ok {
+		t.Error("Expected MaxOf() to report no values")
+	}
+	if got, _ := MinOf(1, math.NaN(), 0); !math.IsNaN(got) {
+		t.Errorf("MinOf(1, NaN, 0) = %v, want NaN", got)
+	}
+
+	if got := Sum(1, 2, 3); got != 6 {
+		t.Errorf("Sum(1, 2, 3) = %v, want 6", got)
+	}
+	if got := Sum[float64](); got != 0 {
+		t.Errorf("Sum() = %v, want 0", got)
+	}
+
+	if got := Mean(int64(math.MaxInt64), math.MaxInt64); got != math.MaxInt64 {
+		t.Errorf("Mean(MaxInt64, MaxInt64) = %v, want %v", got, float64(math.MaxInt64))
+	}
+	if got := Mean(1, 2); got != 1.5 {
+		t.Errorf("Mean(1, 2) = %v, want 1.5", got)
+	}
+	if got := Mean[int](); !math.IsNaN(got) {
+		t.Errorf("Mean() = %v, want NaN", got)
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(a, b int64) (int64, bool)
+		a, b   int64
+		want   int64
+		wantOK bool
+	}{
+		{name: "CheckedAdd", fn: CheckedAdd[int64], a: 2, b: 3, want: 5, wantOK: true},
+		{name: "CheckedAdd", fn: CheckedAdd[int64], a: math.MaxInt64, b: 1, wantOK: false},
+		{name: "CheckedAdd", fn: CheckedAdd[int64], a: math.MinInt64, b: -1, wantOK: false},
+		{name: "CheckedSub", fn: CheckedSub[int64], a: 2, b: 3, want: -1, wantOK: true},
+		{name: "CheckedSub", fn: CheckedSub[int64], a: math.MinInt64, b: 1, wantOK: false},
+		{name: "CheckedSub", fn: CheckedSub[int64], a: 0, b: math.MaxInt64, want: math.MinInt64 + 1, wantOK: true},
+		{name: "CheckedMul", fn: CheckedMul[int64], a: 2, b: -3, want: -6, wantOK: true},
+		{name: "CheckedMul", fn: CheckedMul[int64], a: 1 << 32, b: 1 << 32, wantOK: false},
+		{name: "CheckedMul", fn: CheckedMul[int64], a: math.MinInt64, b: -1, wantOK: false},
+		{name: "CheckedMul", fn: CheckedMul[int64], a: -1, b: math.MinInt64, wantOK: false},
+		{name: "CheckedMul", fn: CheckedMul[int64], a: math.MinInt64, b: 1, want: math.MinInt64, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.fn(tt.a, tt.b)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("%s(%d, %d) = %v, %v, want %v, %v", tt.name, tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := CheckedAdd[uint8](200, 56); ok {
+		t.Error("Expected CheckedAdd(200, 56) to overflow uint8")
+	}
+	if got, ok := CheckedAdd[uint8](200, 55); got != 255 || !ok {
+		t.Errorf("CheckedAdd(200, 55) = %v, %v, want 255, true", got, ok)
+	}
+	if _, ok := CheckedSub[uint](1, 2); ok {
+		t.Error("Expected CheckedSub(1, 2) to overflow uint")
+	}
+	if _, ok := CheckedMul[uint16](256, 256); ok {
+		t.Error("Expected CheckedMul(256, 256) to overflow uint16")
+	}
+	if got, ok := CheckedMul[int8](-8, 16); got != -128 || !ok {
+		t.Errorf("CheckedMul(-8, 16) = %v, %v, want -128, true", got, ok)
+	}
+}
+
+func TestSaturatingArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int8
+		want int8
+	}{
+		{name: "add", got: SaturatingAdd[int8](100, 20), want: 120},
+		{name: "add max", got: SaturatingAdd[int8](100, 100), want: math.MaxInt8},
+		{name: "add min", got: SaturatingAdd[int8](-100, -100), want: math.MinInt8},
+		{name: "sub min", got: SaturatingSub[int8](-100, 100), want: math.MinInt8},
+		{name: "sub max", got: SaturatingSub[int8](100, -100), want: math.MaxInt8},
+		{name: "mul max", got: SaturatingMul[int8](-100, -2), want: math.MaxInt8},
+		{name: "mul min", got: SaturatingMul[int8](100, -2), want: math.MinInt8},
+		{name: "mul min by -1", got: SaturatingMul[int8](math.MinInt8, -1), want: math.MaxInt8},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if got := SaturatingSub[uint](1, 2); got != 0 {
+		t.Errorf("SaturatingSub(1, 2) = %v, want 0", got)
+	}
+	if got := SaturatingAdd[uint32](math.MaxUint32, 1); got != math.MaxUint32 {
+		t.Errorf("SaturatingAdd(MaxUint32, 1) = %v, want %v", got, uint32(math.MaxUint32))
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	// Each row divides by 2 or 4, so the quotients read as 2.5, -2.5, 1.75 and so on.
+	tests := []struct {
+		a, b int
+		want map[RoundingMode]int
+	}{
+		{a: 5, b: 2, want: map[RoundingMode]int{RoundHalfEven: 2, RoundHalfUp: 3, RoundHalfDown: 2, RoundUp: 3, RoundDown: 2, RoundCeiling: 3, RoundFloor: 2}},
+		{a: -5, b: 2, want: map[RoundingMode]int{RoundHalfEven: -2, RoundHalfUp: -3, RoundHalfDown: -2, RoundUp: -3, RoundDown: -2, RoundCeiling: -2, RoundFloor: -3}},
+		{a: 7, b: -2, want: map[RoundingMode]int{RoundHalfEven: -4, RoundHalfUp: -4, RoundHalfDown: -3, RoundUp: -4, RoundDown: -3, RoundCeiling: -3, RoundFloor: -4}},
+		{a: 7, b: 4, want: map[RoundingMode]int{RoundHalfEven: 2, RoundHalfUp: 2, RoundHalfDown: 2, RoundUp: 2, RoundDown: 1, RoundCeiling: 2, RoundFloor: 1}},
+		{a: -5, b: -4, want: map[RoundingMode]int{RoundHalfEven: 1, RoundHalfUp: 1, RoundHalfDown: 1, RoundUp: 2, RoundDown: 1, RoundCeiling: 2, RoundFloor: 1}},
+		{a: 8, b: 4, want: map[RoundingMode]int{RoundHalfEven: 2, RoundUp: 2, RoundFloor: 2}},
+	}
+
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			if got, ok := DivRound(tt.a, tt.b, mode); got != want || !ok {
+				t.Errorf("DivRound(%d, %d, %v) = %v, %v, want %v, true", tt.a, tt.b, mode, got, ok, want)
+			}
+		}
+	}
+
+	edges := []struct {
+		name   string
+		a, b   int8
+		mode   RoundingMode
+		want   int8
+		wantOK bool
+	}{
+		{name: "by zero", a: 1, b: 0, mode: RoundHalfEven, wantOK: false},
+		{name: "min by -1", a: math.MinInt8, b: -1, mode: RoundHalfEven, wantOK: false},
+		{name: "min by min+1", a: math.MinInt8, b: math.MinInt8 + 1, mode: RoundUp, want: 2, wantOK: true},
+		{name: "min+1 by min", a: math.MinInt8 + 1, b: math.MinInt8, mode: RoundHalfEven, want: 1, wantOK: true},
+		{name: "just over half of min", a: -65, b: math.MinInt8, mode: RoundHalfDown, want: 1, wantOK: true},
+	}
+
+	for _, tt := range edges {
+		got, ok := DivRound(tt.a, tt.b, tt.mode)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("DivRound(%d, %d, %v) %s = %v, %v, want %v, %v", tt.a, tt.b, tt.mode, tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if got, ok := DivRound[uint8](255, 2, RoundHalfUp); got != 128 || !ok {
+		t.Errorf("DivRound(255, 2, half-up) = %v, %v, want 128, true", got, ok)
+	}
+	if got, ok := DivRound[uint8](254, 4, RoundHalfEven); got != 64 || !ok {
+		t.Errorf("DivRound(254, 4, half-even) = %v, %v, want 64, true", got, ok)
+	}
+}
@@ -0,0 +1,176 @@
+package goutil
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPanicLimiter is used by Go to keep a goroutine that panics on every call from flooding the logs.
+var defaultPanicLimiter = NewPanicLimiter(time.Second, 1)
+
+// PanicReport is passed to the error handler wrapped by a PanicLimiter instead of the raw panic value.
+// Suppressed is the number of panics with the same signature that were swallowed since the previous report.
+type PanicReport struct {
+	Value      interface{}
+	Signature  string
+	Stack      []byte
+	Suppressed uint64
+}
+
+// String returns the panic value, followed by a summary of the suppressed occurrences if there are any.
+func (r *PanicReport) String() string {
+	if r.Suppressed == 0 {
+		return fmt.Sprint(r.Value)
+	}
+
+	return fmt.Sprintf("%v (%d more occurrences suppressed)", r.Value, r.Suppressed)
+}
+
+// PanicStats holds the counters that a PanicLimiter keeps for one stack signature.
+type PanicStats struct {
+	Signature  string
+	Value      interface{} // the most recent panic value
+	Total      uint64
+	Reported   uint64
+	Suppressed uint64
+	FirstSeen  time.Time
+	LastSeen   time.Time
+}
+
+// PanicLimiter deduplicates panics by stack signature and limits how often each signature is reported.
+// Within every interval at most burst panics of a signature reach the error handler,
+// the rest are counted and summarized in the next report.
+// A PanicLimiter is safe for concurrent use.
+type PanicLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu   sync.Mutex
+	sigs map[string]*panicEntry
+	now  func() time.Time
+}
+
+type panicEntry struct {
+	stats       PanicStats
+	windowStart time.Time
+	inWindow    int
+	pending     uint64 // suppressed since the last report
+}
+
+// NewPanicLimiter creates a PanicLimiter that reports at most burst panics per signature in each interval.
+// A burst less than 1 is treated as 1.
+func NewPanicLimiter(interval time.Duration, burst int) *PanicLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &PanicLimiter{
+		interval: interval,
+		burst:    burst,
+		sigs:     make(map[string]*panicEntry),
+		now:      time.Now,
+	}
+}
+
+// Handler wraps errorHandler so that repeated panics are rate limited per stack signature.
+// The returned function must be called from the deferred recover of the panicking goroutine,
+// as GoWithErrorHandler does, so that the stack of the panic can be inspected.
+// errorHandler receives a *PanicReport.
+func (l *PanicLimiter) Handler(errorHandler func(err interface{})) func(err interface{}) {
+	return func(err interface{}) {
+		stack := debug.Stack()
+		if report := l.record(err, stack); report != nil {
+			errorHandler(report)
+		}
+	}
+}
+
+// record updates the counters of the panic's signature and returns a report if it should be handled.
+func (l *PanicLimiter) record(value interface{}, stack []byte) *PanicReport {
+	sig := stackSignature(stack)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.sigs[sig]
+	if !ok {
+		e = &panicEntry{stats: PanicStats{Signature: sig, FirstSeen: now}, windowStart: now}
+		l.sigs[sig] = e
+	}
+
+	e.stats.Value = value
+	e.stats.Total++
+	e.stats.LastSeen = now
+
+	if now.Sub(e.windowStart) >= l.interval {
+		e.windowStart = now
+		e.inWindow = 0
+	}
+
+	if e.inWindow >= l.burst {
+		e.pending++
+		e.stats.Suppressed++
+		return nil
+	}
+
+	e.inWindow++
+	e.stats.Reported++
+	report := &PanicReport{Value: value, Signature: sig, Stack: stack, Suppressed: e.pending}
+	e.pending = 0
+
+	return report
+}
+
+// Stats returns the counters of every signature seen so far, most frequent first.
+func (l *PanicLimiter) Stats() []PanicStats {
+	l.mu.Lock()
+	stats := make([]PanicStats, 0, len(l.sigs))
+	for _, e := range l.sigs {
+		stats = append(stats, e.stats)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].Signature < stats[j].Signature
+	})
+
+	return stats
+}
+
+// stackSignature hashes a stack trace after removing the parts that differ between occurrences of the same panic:
+// goroutine ids, argument values and program counter offsets.
+func stackSignature(stack []byte) string {
+	h := fnv.New64a()
+
+	for _, line := range strings.Split(string(stack), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+
+		if i := strings.Index(line, " in goroutine "); i >= 0 {
+			line = line[:i]
+		} else if i := strings.LastIndex(line, " +0x"); i >= 0 {
+			line = line[:i]
+		} else if strings.HasSuffix(line, ")") {
+			if i := strings.LastIndexByte(line, '('); i > 0 {
+				line = line[:i]
+			}
+		}
+
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+
+	return strconv.FormatUint(h.Sum64(), 16)
+}
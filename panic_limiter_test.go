@@ -0,0 +1,101 @@
+package goutil
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPanicLimiterSuppressesRepeatedPanics(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewPanicLimiter(time.Second, 1)
+	limiter.now = func() time.Time { return now }
+
+	var reports []*PanicReport
+	handler := limiter.Handler(func(err interface{}) {
+		reports = append(reports, err.(*PanicReport))
+	})
+
+	panicAndRecover := func() {
+		defer func() {
+			if err := recover(); err != nil {
+				handler(err)
+			}
+		}()
+		panic("boom")
+	}
+
+	// Panics must come from the same call site to share a stack signature,
+	// so the window is advanced from inside the loop.
+	for i := 0; i <= 100; i++ {
+		if i == 100 {
+			now = now.Add(time.Second)
+		}
+		panicAndRecover()
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+	if reports[0].Suppressed != 0 || reports[0].String() != "boom" {
+		t.Errorf("Unexpected first report: %v", reports[0])
+	}
+	if want := "boom (99 more occurrences suppressed)"; reports[1].String() != want {
+		t.Errorf("Expected %q, got %q", want, reports[1].String())
+	}
+
+	stats := limiter.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected 1 signature, got %d", len(stats))
+	}
+	if stats[0].Total != 101 || stats[0].Reported != 2 || stats[0].Suppressed != 99 {
+		t.Errorf("Unexpected stats: %+v", stats[0])
+	}
+}
+
+func TestPanicLimiterDistinguishesSignatures(t *testing.T) {
+	limiter := NewPanicLimiter(time.Hour, 2)
+
+	var mu sync.Mutex
+	count := 0
+	handler := limiter.Handler(func(err interface{}) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+
+	first := func() {
+		defer func() { handler(recover()) }()
+		panic("first")
+	}
+	second := func() {
+		defer func() { handler(recover()) }()
+		panic("second")
+	}
+
+	for i := 0; i < 5; i++ {
+		first()
+		second()
+	}
+
+	if count != 4 {
+		t.Errorf("Expected 4 reports (burst of 2 per signature), got %d", count)
+	}
+	if len(limiter.Stats()) != 2 {
+		t.Errorf("Expected 2 signatures, got %d", len(limiter.Stats()))
+	}
+}
+
+func TestStackSignature(t *testing.T) {
+	a := "goroutine 7 [running]:\nmain.f(0xc000012345, 0x1)\n\t/src/main.go:10 +0x1d\ncreated by main.main in goroutine 1\n\t/src/main.go:20 +0x25\n"
+	b := "goroutine 42 [running]:\nmain.f(0xc000099999, 0x2)\n\t/src/main.go:10 +0x2f\ncreated by main.main in goroutine 3\n\t/src/main.go:20 +0x25\n"
+	c := strings.Replace(a, "main.go:10", "main.go:11", 1)
+
+	if stackSignature([]byte(a)) != stackSignature([]byte(b)) {
+		t.Errorf("Expected stacks differing only in ids and offsets to share a signature")
+	}
+	if stackSignature([]byte(a)) == stackSignature([]byte(c)) {
+		t.Errorf("Expected stacks from different lines to have different signatures")
+	}
+}
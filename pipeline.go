@@ -0,0 +1,147 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBulkheadFull is returned when a bulkhead policy rejects an execution because all its slots are taken.
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// PolicyError is returned by a Pipeline when a policy rejects or aborts an execution, as opposed to
+// the executed function failing. Err matches ErrTimeout, ErrCircuitOpen or ErrBulkheadFull with errors.Is.
+type PolicyError struct {
+	Policy string
+	Err    error
+}
+
+func (e *PolicyError) Error() string {
+	return e.Policy + ": " + e.Err.Error()
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// Operation is a function executed by a Pipeline.
+type Operation func(ctx context.Context) error
+
+// Policy adds one resilience concern, such as a timeout or retries, around the next Operation of a Pipeline.
+type Policy func(next Operation) Operation
+
+// Pipeline executes functions through a list of policies. The first policy is the outermost one:
+// NewPipeline(a, b, c).Execute(ctx, fn) runs a(b(c(fn))). A common order is
+// FallbackPolicy, TimeoutPolicy for the whole execution, RetryPolicy, BreakerPolicy,
+// TimeoutPolicy for each attempt, then BulkheadPolicy.
+// A Pipeline keeps the state of its policies, such as the slots of a bulkhead,
+// so it should be built once and shared.
+type Pipeline struct {
+	policies []Policy
+}
+
+// NewPipeline returns a Pipeline applying policies, outermost first.
+func NewPipeline(policies ...Policy) *Pipeline {
+	return &Pipeline{policies: policies}
+}
+
+// Execute runs fn through the policies of the pipeline.
+func (p *Pipeline) Execute(ctx context.Context, fn Operation) error {
+	op := fn
+	for i := len(p.policies) - 1; i >= 0; i-- {
+		op = p.policies[i](op)
+	}
+
+	return op(ctx)
+}
+
+// TimeoutPolicy gives the rest of the pipeline at most d to complete.
+// The timeout is cooperative: the executed function must return when its context is done.
+// If the timeout expires, the error is a *PolicyError wrapping ErrTimeout and context.DeadlineExceeded.
+func TimeoutPolicy(d time.Duration) Policy {
+	return func(next Operation) Operation {
+		return func(ctx context.Context) error {
+			timeoutCtx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			err := next(timeoutCtx)
+			if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+				return &PolicyError{Policy: "timeout", Err: fmt.Errorf("%w after %v: %w", ErrTimeout, d, timeoutCtx.Err())}
+			}
+
+			return err
+		}
+	}
+}
+
+// RetryPolicy retries the rest of the pipeline with backoff and the retry options, see RetryWithContext.
+// An error matching ErrCircuitOpen, from a breaker further down the pipeline, stops the retries immediately.
+func RetryPolicy(backoff Backoff, opts ...RetryOption) Policy {
+	return func(next Operation) Operation {
+		return func(ctx context.Context) error {
+			return exponentialBackoffWithCtx(ctx, backoff, func(ctx context.Context) (bool, error) {
+				err := next(ctx)
+				if errors.Is(err, ErrCircuitOpen) {
+					return false, Permanent(err)
+				}
+
+				return err == nil, err
+			}, opts...)
+		}
+	}
+}
+
+// BreakerPolicy runs the rest of the pipeline through breaker.
+// A rejected execution returns a *PolicyError wrapping ErrCircuitOpen.
+// A panic of the rest of the pipeline is recorded as a failure, like with CircuitBreaker.Execute.
+func BreakerPolicy(breaker *CircuitBreaker) Policy {
+	return func(next Operation) Operation {
+		return func(ctx context.Context) error {
+			record, err := breaker.allow()
+			if err != nil {
+				return &PolicyError{Policy: "circuit breaker", Err: err}
+			}
+
+			return breaker.guard(record, func() error { return next(ctx) })
+		}
+	}
+}
+
+// BulkheadPolicy allows at most maxConcurrent executions of the rest of the pipeline at the same time.
+// An execution arriving while all slots are taken returns a *PolicyError wrapping ErrBulkheadFull.
+// BulkheadPolicy panics if maxConcurrent is not positive.
+func BulkheadPolicy(maxConcurrent int) Policy {
+	if maxConcurrent <= 0 {
+		panic("goutil: non-positive maxConcurrent for BulkheadPolicy")
+	}
+
+	sem := make(chan struct{}, maxConcurrent)
+
+	return func(next Operation) Operation {
+		return func(ctx context.Context) error {
+			select {
+			case sem <- struct{}{}:
+			default:
+				return &PolicyError{Policy: "bulkhead", Err: ErrBulkheadFull}
+			}
+			defer func() { <-sem }()
+
+			return next(ctx)
+		}
+	}
+}
+
+// FallbackPolicy calls fallback with the error of the rest of the pipeline when it fails,
+// and returns the result of fallback instead.
+func FallbackPolicy(fallback func(ctx context.Context, err error) error) Policy {
+	return func(next Operation) Operation {
+		return func(ctx context.Context) error {
+			if err := next(ctx); err != nil {
+				return fallback(ctx, err)
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPipelineOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) Policy {
+		return func(next Operation) Operation {
+			return func(ctx context.Context) error {
+				order = append(order, name+" in")
+				err := next(ctx)
+				order = append(order, name+" out")
+				return err
+			}
+		}
+	}
+
+	err := NewPipeline(trace("a"), trace("b")).Execute(context.Background(), func(context.Context) error {
+		order = append(order, "fn")
+		return nil
+	})
+
+	want := []string{"a in", "b in", "fn", "b out", "a out"}
+	if err != nil || len(order) != len(want) {
+		t.Fatalf("Execute() = %v with order %v, want nil with %v", err, order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Execute() order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestPipelineTimeoutRetryFallback(t *testing.T) {
+	calls := 0
+	pipeline := NewPipeline(
+		FallbackPolicy(func(ctx context.Context, err error) error {
+			if !errors.Is(err, ErrTimeout) {
+				t.Errorf("Expected the fallback to receive a timeout, got %v", err)
+			}
+			return nil
+		}),
+		RetryPolicy(BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond}),
+		TimeoutPolicy(5*time.Millisecond),
+	)
+
+	err := pipeline.Execute(context.Background(), func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err != nil {
+		t.Errorf("Execute() error = %v, want the fallback to recover", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected each attempt to time out and be retried, got %d calls", calls)
+	}
+}
+
+func TestPipelineTimeoutError(t *testing.T) {
+	err := NewPipeline(TimeoutPolicy(time.Millisecond)).Execute(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || policyErr.Policy != "timeout" {
+		t.Fatalf("Execute() error = %v, want a timeout *PolicyError", err)
+	}
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v to match ErrTimeout and context.DeadlineExceeded", err)
+	}
+}
+
+func TestPipelineBreakerStopsRetries(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{ConsecutiveFailures: 2, Cooldown: time.Hour})
+	pipeline := NewPipeline(
+		RetryPolicy(ConstantBackoff{Delay: time.Millisecond}),
+		BreakerPolicy(breaker),
+	)
+
+	calls := 0
+	err := pipeline.Execute(context.Background(), func(context.Context) error {
+		calls++
+		return ErrCustom
+	})
+
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Execute() error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the open breaker to stop the retries after 2 calls, got %d", calls)
+	}
+}
+
+func TestPipelineBulkhead(t *testing.T) {
+	pipeline := NewPipeline(BulkheadPolicy(1))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- pipeline.Execute(context.Background(), func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	err := pipeline.Execute(context.Background(), func(context.Context) error { return nil })
+	if !errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("Execute() error = %v, want %v", err, ErrBulkheadFull)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Execute() error = %v, want nil", err)
+	}
+
+	if err := pipeline.Execute(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected the released slot to be reusable, got %v", err)
+	}
+}
+
+func TestPipelineBulkheadInvalidLimit(t *testing.T) {
+	for _, maxConcurrent := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected BulkheadPolicy(%d) to panic", maxConcurrent)
+				}
+			}()
+			BulkheadPolicy(maxConcurrent)
+		}()
+	}
+}
+
+func TestPipelineBreakerPanic(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{ConsecutiveFailures: 1, Cooldown: time.Hour})
+	pipeline := NewPipeline(BreakerPolicy(breaker))
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to continue, recovered %v", r)
+			}
+		}()
+		_ = pipeline.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	}()
+
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected a panicking execution to count as a failure, got %v", breaker.State())
+	}
+}
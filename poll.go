@@ -0,0 +1,129 @@
+package goutil
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PollOption configures PollUntil and PollImmediateUntil.
+type PollOption func(*pollOptions)
+
+type pollOptions struct {
+	clock        Clock
+	fixedRate    bool
+	jitterFactor float64
+	jitterSource JitterSource
+}
+
+// WithPollClock sets the Clock used to wait between checks. It defaults to RealClock.
+func WithPollClock(clock Clock) PollOption {
+	return func(o *pollOptions) {
+		o.clock = clock
+	}
+}
+
+// WithFixedRate measures the interval from the start of each check instead of from its end,
+// so that a slow condition does not push back the following checks.
+// A check that takes longer than the interval is followed by the next one immediately.
+func WithFixedRate() PollOption {
+	return func(o *pollOptions) {
+		o.fixedRate = true
+	}
+}
+
+// WithPollJitter adds a random delay of up to jitterFactor times the interval before every check.
+func WithPollJitter(jitterFactor float64) PollOption {
+	return func(o *pollOptions) {
+		o.jitterFactor = jitterFactor
+	}
+}
+
+// WithPollJitterSource sets the source of the jitter added by WithPollJitter.
+func WithPollJitterSource(src JitterSource) PollOption {
+	return func(o *pollOptions) {
+		o.jitterSource = src
+	}
+}
+
+// PollUntil waits interval, then checks condition every interval until it returns true or an error, or ctx is done.
+// Unlike the retry helpers, an error from condition stops the polling and is returned as is.
+// If ctx is done first, the error matches ErrTimeout and the error of ctx with errors.Is.
+// The context passed to condition carries the Attempt, see AttemptFromContext.
+// PollUntil panics if interval is not positive.
+func PollUntil(ctx context.Context, interval time.Duration, condition RetryableFuncWithContext, opts ...PollOption) error {
+	return poll(ctx, interval, false, condition, opts)
+}
+
+// PollImmediateUntil is like PollUntil but checks condition once before waiting the first interval.
+func PollImmediateUntil(ctx context.Context, interval time.Duration, condition RetryableFuncWithContext, opts ...PollOption) error {
+	return poll(ctx, interval, true, condition, opts)
+}
+
+// WaitFor waits until a value is received from ch, or ch is closed, and returns it.
+// If ctx is done first, the error matches ErrTimeout and the error of ctx with errors.Is.
+func WaitFor[T any](ctx context.Context, ch <-chan T) (T, error) {
+	select {
+	case v := <-ch:
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+}
+
+func poll(ctx context.Context, interval time.Duration, immediate bool, condition RetryableFuncWithContext, opts []PollOption) error {
+	if interval <= 0 {
+		panic("goutil: non-positive interval for PollUntil")
+	}
+
+	o := pollOptions{clock: RealClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	next := func(elapsed time.Duration) time.Duration {
+		delay := interval
+		if o.jitterFactor > 0 {
+			delay = addJitter(o.jitterSource, delay, o.jitterFactor)
+		}
+		if o.fixedRate {
+			delay -= elapsed
+			if delay < 0 {
+				delay = 0
+			}
+		}
+		return delay
+	}
+
+	var (
+		first   time.Time
+		elapsed time.Duration
+	)
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 || !immediate {
+			if err := sleepWithContext(ctx, o.clock, next(elapsed)); err != nil {
+				return fmt.Errorf("%w: %w", ErrTimeout, err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+		}
+
+		start := o.clock.Now()
+		if attempt == 1 {
+			first = start
+		}
+
+		done, err := condition(context.WithValue(ctx, attemptKey{}, Attempt{
+			Number:  attempt,
+			Elapsed: start.Sub(first),
+		}))
+		if err != nil || done {
+			return err
+		}
+
+		elapsed = o.clock.Now().Sub(start)
+	}
+}
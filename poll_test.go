@@ -0,0 +1,166 @@
+package goutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qcrao/goutil"
+	"github.com/qcrao/goutil/goutiltest"
+)
+
+func TestPollUntil(t *testing.T) {
+	tests := []struct {
+		name      string
+		poll      func(context.Context, time.Duration, goutil.RetryableFuncWithContext, ...goutil.PollOption) error
+		wantWaits int
+	}{
+		{name: "PollUntil", poll: goutil.PollUntil, wantWaits: 3},
+		{name: "PollImmediateUntil", poll: goutil.PollImmediateUntil, wantWaits: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+			var attempts []int
+			errc := make(chan error, 1)
+
+			go func() {
+				errc <- tt.poll(context.Background(), time.Second, func(ctx context.Context) (bool, error) {
+					attempt, _ := goutil.AttemptFromContext(ctx)
+					attempts = append(attempts, attempt.Number)
+					return len(attempts) == 3, nil
+				}, goutil.WithPollClock(clock))
+			}()
+
+			for i := 0; i < tt.wantWaits; i++ {
+				clock.BlockUntil(1)
+				clock.Advance(time.Second)
+			}
+
+			if err := <-errc; err != nil {
+				t.Errorf("%s() error = %v, want nil", tt.name, err)
+			}
+			if len(attempts) != 3 || attempts[2] != 3 {
+				t.Errorf("Expected attempts [1 2 3], got %v", attempts)
+			}
+			if clock.Waiters() != 0 {
+				t.Errorf("Expected no timer left, got %d", clock.Waiters())
+			}
+		})
+	}
+}
+
+func TestPollUntilConditionError(t *testing.T) {
+	errNotReady := errors.New("not ready")
+	calls := 0
+
+	err := goutil.PollImmediateUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+		calls++
+		return false, errNotReady
+	})
+
+	if err != errNotReady || calls != 1 {
+		t.Errorf("PollImmediateUntil() error = %v after %d calls, want %v after 1", err, calls, errNotReady)
+	}
+}
+
+func TestPollUntilTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := goutil.PollUntil(ctx, time.Millisecond, func(context.Context) (bool, error) {
+		return false, nil
+	})
+
+	if !errors.Is(err, goutil.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PollUntil() error = %v, want %v and %v", err, goutil.ErrTimeout, context.DeadlineExceeded)
+	}
+}
+
+func TestPollUntilFixedRate(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	checks := make(chan time.Time, 10)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- goutil.PollImmediateUntil(context.Background(), 3*time.Second, func(context.Context) (bool, error) {
+			checks <- clock.Now()
+			// Every check takes a second.
+			clock.Advance(time.Second)
+			return len(checks) == 3, nil
+		}, goutil.WithPollClock(clock), goutil.WithFixedRate())
+	}()
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(2 * time.Second)
+	}
+
+	if err := <-errc; err != nil {
+		t.Fatalf("PollImmediateUntil() error = %v", err)
+	}
+
+	close(checks)
+	var want int64
+	for got := range checks {
+		if got.Unix() != want {
+			t.Errorf("Expected a check at %ds, got %ds", want, got.Unix())
+		}
+		want += 3
+	}
+}
+
+func TestPollUntilJitter(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- goutil.PollUntil(context.Background(), time.Second, func(context.Context) (bool, error) {
+			return true, nil
+		}, goutil.WithPollClock(clock), goutil.WithPollJitter(0.5), goutil.WithPollJitterSource(constantSource(0.5)))
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	select {
+	case err := <-errc:
+		t.Fatalf("Expected the jitter to delay the check, returned %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(250 * time.Millisecond)
+	if err := <-errc; err != nil {
+		t.Errorf("PollUntil() error = %v, want nil", err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	ch := make(chan int, 1)
+	ch <- 42
+
+	if got, err := goutil.WaitFor(context.Background(), ch); got != 42 || err != nil {
+		t.Errorf("WaitFor() = %v, %v, want 42, nil", got, err)
+	}
+
+	closed := make(chan struct{})
+	close(closed)
+	if _, err := goutil.WaitFor(context.Background(), closed); err != nil {
+		t.Errorf("WaitFor() error = %v for a closed channel, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := goutil.WaitFor(ctx, make(chan int)); !errors.Is(err, goutil.ErrTimeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitFor() error = %v, want %v and %v", err, goutil.ErrTimeout, context.Canceled)
+	}
+}
+
+// constantSource is a JitterSource that always returns the same number.
+type constantSource float64
+
+func (s constantSource) Float64() float64 {
+	return float64(s)
+}
@@ -7,22 +7,27 @@ import (
 
 // Go starts a goroutine with recovery capability.
 // If the goroutine panics, it will recover and use a default error handler.
+// Repeated panics with the same stack are rate limited, see PanicLimiter.
 func Go(fn func()) {
-	GoWithErrorHandler(fn, defaultErrorHandler)
+	GoWithErrorHandler(fn, defaultPanicLimiter.Handler(defaultErrorHandler))
 }
 
 // GoWithErrorHandler starts a goroutine with a custom error handler.
 // If the goroutine panics, it will recover and use the provided error handler.
 func GoWithErrorHandler(fn func(), errorHandler func(err interface{})) {
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				errorHandler(err)
-			}
-		}()
+	go runWithErrorHandler(fn, errorHandler)
+}
 
-		fn()
+// runWithErrorHandler calls fn in the current goroutine and passes a recovered panic to errorHandler.
+func runWithErrorHandler(fn func(), errorHandler func(err interface{})) {
+	defer func() {
+		if err := recover(); err != nil {
+			newMetricsReporter(funcName(fn)).inc(MetricPanicsRecovered)
+			errorHandler(err)
+		}
 	}()
+
+	fn()
 }
 
 // defaultErrorHandler is a recovery function that logs the panic and prints the stack trace.
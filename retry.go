@@ -3,66 +3,81 @@ package goutil
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
-	"math/rand"
 	"time"
 )
 
+// DefaultMaxElapsedTime bounds RetryWithContext when the context has no deadline, see WithMaxElapsedTime.
+const DefaultMaxElapsedTime = 15 * time.Minute
+
 var ErrTimeout = errors.New("timed out waiting for the condition")
 var ErrNotSetDeadline = errors.New("context doesn't set deadline")
 
+// The preset policies as package variables, kept for existing callers.
+// Any caller can assign them and so change the policy of every other user:
+// the constructors below return a new copy on every call instead.
 var (
-	DefaultRetry   = BackoffWait{TotalRuns: 2, BaseDuration: time.Second, Factor: 2.0, JitterFactor: 0.1}
-	FastRetry      = BackoffWait{TotalRuns: 2, BaseDuration: 50 * time.Millisecond, Factor: 2.0, JitterFactor: 0.5}
-	UnlimitedRetry = BackoffWait{TotalRuns: math.MaxInt32, BaseDuration: time.Second, Factor: 2.0, JitterFactor: 0.5}
-	NoRetry        = BackoffWait{TotalRuns: 1}
+	// Deprecated: use NewDefaultRetry.
+	DefaultRetry = NewDefaultRetry()
+	// Deprecated: use NewFastRetry.
+	FastRetry = NewFastRetry()
+	// Deprecated: use NewUnlimitedRetry.
+	UnlimitedRetry = NewUnlimitedRetry()
+	// Deprecated: use NewNoRetry.
+	NoRetry = NewNoRetry()
 )
 
-// BackoffWait encapsulates parameters that control the behavior of backoff mechanism.
-// TotalRuns denotes the maximum number of times the function is executed,
-// BaseDuration is the initial waiting time before function execution,
-// Factor is the multiplier for exponential growth of waiting time,
-// JitterFactor is the factor for random increase to the waiting time.
-type BackoffWait struct {
-	TotalRuns    int           `json:"total_runs"`
-	BaseDuration time.Duration `json:"base_duration"`
-	Factor       float64       `json:"factor"`
-	JitterFactor float64       `json:"jitter_factor"`
+// NewDefaultRetry returns a policy retrying once after about a second.
+func NewDefaultRetry() BackoffWait {
+	return BackoffWait{TotalRuns: 2, BaseDuration: time.Second, Factor: 2.0, JitterFactor: 0.1}
 }
 
-// wait returns a time duration to wait before next function execution.
-// It updates the BackoffWait's BaseDuration and TotalRuns after each call.
-func (b *BackoffWait) wait() time.Duration {
-	if b.TotalRuns < 1 {
-		if b.JitterFactor > 0 {
-			return addJitter(b.BaseDuration, b.JitterFactor)
-		}
-
-		return b.BaseDuration
-	}
-
-	b.TotalRuns--
-
-	duration := b.BaseDuration
+// NewFastRetry returns a policy retrying once after about 50ms.
+func NewFastRetry() BackoffWait {
+	return BackoffWait{TotalRuns: 2, BaseDuration: 50 * time.Millisecond, Factor: 2.0, JitterFactor: 0.5}
+}
 
-	if b.Factor != 0 {
-		b.BaseDuration = time.Duration(float64(b.BaseDuration) * b.Factor)
-	}
+// NewUnlimitedRetry returns a policy retrying with exponential backoff from a second, with no limit on the number of attempts.
+func NewUnlimitedRetry() BackoffWait {
+	return BackoffWait{TotalRuns: math.MaxInt32, BaseDuration: time.Second, Factor: 2.0, JitterFactor: 0.5}
+}
 
-	if b.JitterFactor > 0 {
-		duration = addJitter(duration, b.JitterFactor)
-	}
+// NewNoRetry returns a policy making a single attempt.
+func NewNoRetry() BackoffWait {
+	return BackoffWait{TotalRuns: 1}
+}
 
-	return duration
+// BackoffWait encapsulates parameters that control the behavior of backoff mechanism.
+// TotalRuns denotes the maximum number of times the function is executed, a TotalRuns below 1 executes it once like NewNoRetry,
+// BaseDuration is the initial waiting time before function execution,
+// Factor is the multiplier for exponential growth of waiting time,
+// JitterFactor is the factor for random increase to the waiting time,
+// Rand is the source of the jitter, the global math/rand source if nil.
+//
+// A BackoffWait is an immutable value: the retry helpers keep the state of each retry loop in a BackoffState,
+// so one BackoffWait can be shared by concurrent retries. The preset constructors NewDefaultRetry,
+// NewFastRetry, NewUnlimitedRetry and NewNoRetry return a new BackoffWait on every call, so that no caller can
+// change them for the others.
+type BackoffWait struct {
+	TotalRuns    int           `json:"total_runs" yaml:"total_runs"`
+	BaseDuration time.Duration `json:"base_duration" yaml:"base_duration"`
+	Factor       float64       `json:"factor" yaml:"factor"`
+	JitterFactor float64       `json:"jitter_factor" yaml:"jitter_factor"`
+	Rand         JitterSource  `json:"-" yaml:"-"`
 }
 
-// addJitter adds random jitter to the base duration.
-func addJitter(base time.Duration, jitterFactor float64) time.Duration {
+// addJitter adds random jitter drawn from src to the base duration.
+func addJitter(src JitterSource, base time.Duration, jitterFactor float64) time.Duration {
 	if jitterFactor <= 0.0 {
 		jitterFactor = 1.0
 	}
 
-	return base + time.Duration(rand.Float64()*jitterFactor*float64(base))
+	if d := base + scaleDuration(base, randFloat64(src)*jitterFactor); d >= base {
+		return d
+	}
+
+	return maxDuration
 }
 
 // RetryableFunc is a function type that can be retried until it succeeds or meets a certain condition.
@@ -77,81 +92,212 @@ func (cf RetryableFunc) WithContext() RetryableFuncWithContext {
 	}
 }
 
-func exponentialBackoff(backoff BackoffWait, fn RetryableFunc) error {
-	for backoff.TotalRuns > 0 {
-		if done, err := fn(); err != nil || done {
-			return err
-		}
+// Attempt describes the current call of a function retried with a context.
+// Number counts from 1, Elapsed is the time since the first call started,
+// and PrevErr is the error of the previous call, nil for the first one.
+type Attempt struct {
+	Number  int
+	Elapsed time.Duration
+	PrevErr error
+}
 
-		if backoff.TotalRuns == 1 {
-			break
+type attemptKey struct{}
+
+// AttemptFromContext returns the Attempt carried by the context passed to a retried function.
+func AttemptFromContext(ctx context.Context) (Attempt, bool) {
+	a, ok := ctx.Value(attemptKey{}).(Attempt)
+	return a, ok
+}
+
+// exponentialBackoffWithCtx calls fnWithContext until it reports done, returns a permanent error,
+// the backoff runs out or ctx is done. When it gives up, it returns a *RetryError recording every attempt.
+// Waiting between attempts ends as soon as ctx is done, and a wait that would outlast the deadline of ctx is not started.
+// Waits use the clock of the options, while the deadline of ctx is always compared with the real time.
+// backoff is cloned first, so concurrent loops may share it.
+// The context passed to fnWithContext carries the current Attempt, see AttemptFromContext.
+func exponentialBackoffWithCtx(ctx context.Context, backoff Backoff, fnWithContext RetryableFuncWithContext, opts ...RetryOption) error {
+	o := newRetryOptions(opts)
+
+	if _, ok := ctx.Deadline(); !ok && o.maxElapsedTime > 0 {
+		backoff = &maxElapsedTimeBackoff{Backoff: backoff, limit: o.maxElapsedTime, clock: o.clock}
+	}
+	state := NewBackoffState(backoff)
+	metrics := newMetricsReporter(OperationFromContext(ctx))
+
+	finish := func(attempts int, err error) error {
+		if err == nil {
+			metrics.inc(MetricRetrySuccesses)
+			if o.budget != nil {
+				o.budget.Deposit()
+			}
+			if o.onSuccess != nil {
+				o.onSuccess(attempts)
+			}
+		} else {
+			metrics.inc(MetricRetryGiveUps)
+			if o.onGiveUp != nil {
+				o.onGiveUp(attempts, err)
+			}
 		}
 
-		time.Sleep(backoff.wait())
+		return err
 	}
 
-	return ErrTimeout
-}
+	retryErr := &RetryError{}
+	var first time.Time
+	for attempt := 1; ; attempt++ {
+		if ctx.Err() != nil {
+			retryErr.cause = fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+			return finish(attempt-1, retryErr)
+		}
 
-func exponentialBackoffWithCtx(ctx context.Context, backoff BackoffWait, fnWithContext RetryableFuncWithContext) error {
-	for backoff.TotalRuns > 0 {
-		select {
-		case <-ctx.Done():
-			return ErrTimeout
-		default:
-			done, err := fnWithContext(ctx)
-			backoff.TotalRuns--
-
-			if err != nil || done {
-				return err
+		var record func(failure bool)
+		if o.breaker != nil {
+			r, err := o.breaker.allow()
+			if err != nil {
+				retryErr.cause = err
+				return finish(attempt-1, retryErr)
 			}
+			record = r
+		}
 
-			if backoff.TotalRuns <= 0 {
-				break
-			}
+		start := o.clock.Now()
+		if attempt == 1 {
+			first = start
+		}
 
-			time.Sleep(backoff.wait())
+		var done bool
+		call := func() (err error) {
+			done, err = fnWithContext(context.WithValue(ctx, attemptKey{}, Attempt{
+				Number:  attempt,
+				Elapsed: start.Sub(first),
+				PrevErr: retryErr.Last(),
+			}))
+			return err
 		}
 
-		if backoff.TotalRuns <= 0 {
+		var err error
+		if record != nil {
+			err = o.breaker.guard(record, call)
+		} else {
+			err = call()
+		}
+		metrics.inc(MetricRetryAttempts)
+
+		if done {
+			return finish(attempt, err)
+		}
+
+		if err != nil && o.permanent(err) {
+			return finish(attempt, unwrapPermanent(err))
+		}
+
+		retryErr.Attempts = append(retryErr.Attempts, RetryAttempt{Err: err, Time: start})
+
+		delay, ok := state.Next()
+		if !ok {
 			break
 		}
+
+		if hint, ok := o.retryAfter(err); ok {
+			if !state.allows(hint) {
+				retryErr.cause = fmt.Errorf("%w: waiting %v would exceed the retry limits", ErrTimeout, hint)
+				return finish(attempt, retryErr)
+			}
+			delay = hint
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			retryErr.cause = fmt.Errorf("%w: waiting %v would exceed the context deadline: %w", ErrTimeout, delay, context.DeadlineExceeded)
+			return finish(attempt, retryErr)
+		}
+
+		if o.budget != nil && !o.budget.Withdraw() {
+			retryErr.cause = ErrRetryBudgetExhausted
+			return finish(attempt, retryErr)
+		}
+
+		retryErr.Attempts[len(retryErr.Attempts)-1].Delay = delay
+		metrics.observe(MetricRetryDelaySeconds, delay.Seconds())
+		if o.onRetry != nil {
+			o.onRetry(attempt, err, delay)
+		}
+
+		if err := sleepWithContext(ctx, o.clock, delay); err != nil {
+			retryErr.cause = fmt.Errorf("%w: %w", ErrTimeout, err)
+			return finish(attempt, retryErr)
+		}
 	}
 
-	return ErrTimeout
+	if retryErr.Last() == nil {
+		retryErr.cause = ErrTimeout
+	}
+
+	return finish(len(retryErr.Attempts), retryErr)
 }
 
-// RetryWithExponentialBackoff tries a function with exponential backoff, and return the error from the function or timeout.
-func RetryWithExponentialBackoff(backoff BackoffWait, fn RetryableFunc) (err error) {
-	waitErr := exponentialBackoff(backoff, func() (bool, error) {
-		var done bool
-		done, err = fn()
-		return done, nil // swallow err in the process
-	})
+// sleepWithContext waits for d on clock, or returns ctx.Err() as soon as ctx is done.
+func sleepWithContext(ctx context.Context, clock Clock, d time.Duration) error {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
 
-	if waitErr != nil && err == nil {
-		return waitErr
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
 	}
+}
+
+// RetryWithExponentialBackoff tries a function with exponential backoff, and return the error from the function or timeout.
+// Any Backoff can be used in place of a BackoffWait to choose a different strategy.
+// When the retries run out, the returned *RetryError wraps the errors of every attempt.
+// An error marked with Permanent, or classified as permanent by an option, stops the retries immediately.
+func RetryWithExponentialBackoff(backoff Backoff, fn RetryableFunc, opts ...RetryOption) error {
+	return exponentialBackoffWithCtx(context.Background(), backoff, fn.WithContext(), opts...)
+}
 
-	return err
+// RetryWithContext tries a function with the given backoff until it succeeds, the backoff stops or ctx is done.
+// If ctx has no deadline, retries stop after DefaultMaxElapsedTime unless WithMaxElapsedTime sets another limit.
+// A single backoff value may be shared by concurrent calls.
+// When it gives up, the returned *RetryError wraps the errors of every attempt, and ErrTimeout if ctx is done.
+func RetryWithContext(ctx context.Context, backoff Backoff, f RetryableFuncWithContext, opts ...RetryOption) error {
+	opts = append([]RetryOption{WithMaxElapsedTime(DefaultMaxElapsedTime)}, opts...)
+
+	return exponentialBackoffWithCtx(ctx, backoff, f, opts...)
 }
 
 // RetryWithExponentialBackoffUntilTimeout tries a function with exponential backoff until it succeeds or the context is cancelled (timeout).
-func RetryWithExponentialBackoffUntilTimeout(ctx context.Context, f RetryableFuncWithContext) (err error) {
+// Use RetryWithContext to choose the backoff or to retry without a deadline.
+// When it gives up, the returned *RetryError wraps ErrTimeout and the errors of every attempt.
+// An error marked with Permanent, or classified as permanent by an option, stops the retries immediately.
+func RetryWithExponentialBackoffUntilTimeout(ctx context.Context, f RetryableFuncWithContext, opts ...RetryOption) error {
 	if _, ok := ctx.Deadline(); !ok {
 		return ErrNotSetDeadline
 	}
 
 	backOff := BackoffWait{TotalRuns: math.MaxInt32, BaseDuration: time.Second, Factor: 1.5, JitterFactor: 0.5}
-	waitErr := exponentialBackoffWithCtx(ctx, backOff, func(ctx context.Context) (bool, error) {
-		var done bool
-		done, err = f(ctx)
-		return done, nil // swallow err in the process
-	})
 
-	if waitErr != nil && err == nil {
-		return waitErr
+	return exponentialBackoffWithCtx(ctx, backOff, f, opts...)
+}
+
+// Retry calls fn with exponential backoff until it returns a nil error, and returns the value of that call.
+// fn is called at least once, and again after every delay returned by policy. If every call fails, or ctx is done first,
+// Retry returns a *RetryError wrapping the errors of every call.
+// An error marked with Permanent, or classified as permanent by an option, stops the retries immediately.
+func Retry[T any](ctx context.Context, policy Backoff, fn func(context.Context) (T, error), opts ...RetryOption) (T, error) {
+	var result T
+
+	err := exponentialBackoffWithCtx(ctx, policy, func(ctx context.Context) (bool, error) {
+		var err error
+		result, err = fn(ctx)
+		return err == nil, err
+	}, opts...)
+
+	if err != nil {
+		var zero T
+		return zero, err
 	}
 
-	return err
+	return result, nil
 }
@@ -0,0 +1,60 @@
+package goutil
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrRetryBudgetExhausted is wrapped by the *RetryError returned when a RetryBudget denies a retry.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget limits retries across every caller sharing it, so that retries cannot multiply
+// the load on a failing dependency. It is a token bucket: each retry spends one token,
+// and each successful call deposits a fraction of a token, like the retry throttling of gRPC and Finagle.
+// A RetryBudget is safe for concurrent use.
+type RetryBudget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// NewRetryBudget returns a full RetryBudget holding at most maxTokens tokens,
+// to which every successful call deposits ratio tokens.
+// With a ratio of 0.1, retries are sustained at up to one for every ten successful calls.
+func NewRetryBudget(maxTokens float64, ratio float64) *RetryBudget {
+	return &RetryBudget{tokens: maxTokens, maxTokens: maxTokens, ratio: ratio}
+}
+
+// Deposit records a successful call.
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// Withdraw spends a token for a retry and reports whether one was available.
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Tokens returns the number of tokens left.
+func (b *RetryBudget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens
+}
@@ -0,0 +1,68 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(2, 0.5)
+
+	if !budget.Withdraw() || !budget.Withdraw() {
+		t.Fatal("Expected a full budget to allow 2 retries")
+	}
+	if budget.Withdraw() {
+		t.Fatal("Expected an empty budget to deny retries")
+	}
+
+	budget.Deposit()
+	if budget.Withdraw() {
+		t.Fatal("Expected half a token not to allow a retry")
+	}
+
+	budget.Deposit()
+	if !budget.Withdraw() {
+		t.Fatal("Expected two deposits to allow a retry")
+	}
+
+	for i := 0; i < 10; i++ {
+		budget.Deposit()
+	}
+	if budget.Tokens() != 2 {
+		t.Errorf("Expected tokens to be capped at 2, got %v", budget.Tokens())
+	}
+}
+
+func TestRetryWithBudget(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	budget := NewRetryBudget(3, 1)
+	backoff := ConstantBackoff{Delay: time.Millisecond}
+
+	calls := 0
+	err := RetryWithContext(context.Background(), backoff, func(context.Context) (bool, error) {
+		calls++
+		return false, ErrCustom
+	}, WithRetryBudget(budget))
+
+	if !errors.Is(err, ErrRetryBudgetExhausted) || !errors.Is(err, ErrCustom) {
+		t.Errorf("RetryWithContext() error = %v, want ErrRetryBudgetExhausted and %v", err, ErrCustom)
+	}
+	if calls != 4 {
+		t.Errorf("Expected the budget to allow 3 retries, got %d calls", calls)
+	}
+
+	// A successful call refills one token, allowing a single retry for the next caller.
+	_, _ = Retry(context.Background(), backoff, func(context.Context) (int, error) { return 1, nil }, WithRetryBudget(budget))
+
+	calls = 0
+	_ = RetryWithContext(context.Background(), backoff, func(context.Context) (bool, error) {
+		calls++
+		return false, ErrCustom
+	}, WithRetryBudget(budget))
+
+	if calls != 2 {
+		t.Errorf("Expected the refilled budget to allow 1 retry, got %d calls", calls)
+	}
+}
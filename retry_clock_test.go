@@ -0,0 +1,211 @@
+package goutil_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qcrao/goutil"
+	"github.com/qcrao/goutil/goutiltest"
+)
+
+func TestRetryWithFakeClock(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	start := time.Unix(0, 0)
+	clock := goutiltest.NewFakeClock(start)
+
+	policy := func() goutil.BackoffWait {
+		return goutil.BackoffWait{TotalRuns: 3, BaseDuration: time.Hour, Factor: 2, JitterFactor: 0.5, Rand: goutil.NewJitterSource(1)}
+	}
+
+	// The same seed yields the same jittered delays.
+	expected := policy()
+	var want []time.Duration
+	for attempt := 1; attempt < 3; attempt++ {
+		d, _ := expected.NextDelay(attempt)
+		want = append(want, d)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := goutil.Retry(context.Background(), policy(), func(context.Context) (int, error) {
+			return 0, ErrCustom
+		}, goutil.WithRetryClock(clock))
+		errCh <- err
+	}()
+
+	for _, d := range want {
+		clock.BlockUntil(1)
+		clock.Advance(d)
+	}
+
+	err := <-errCh
+
+	var retryErr *goutil.RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(err, ErrCustom) {
+		t.Fatalf("Retry() error = %v, want a *RetryError wrapping %v", err, ErrCustom)
+	}
+
+	at := start
+	for i, attempt := range retryErr.Attempts {
+		if !attempt.Time.Equal(at) {
+			t.Errorf("attempt %d at %v, want %v", i+1, attempt.Time, at)
+		}
+		if i < len(want) {
+			if attempt.Delay != want[i] {
+				t.Errorf("attempt %d delay = %v, want %v", i+1, attempt.Delay, want[i])
+			}
+			at = at.Add(want[i])
+		}
+	}
+}
+
+func TestRetryMaxElapsedTimeWithFakeClock(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+
+	errCh := make(chan error, 1)
+	calls := 0
+	go func() {
+		errCh <- goutil.RetryWithContext(context.Background(), goutil.ConstantBackoff{Delay: time.Minute}, func(context.Context) (bool, error) {
+			calls++
+			return false, nil
+		}, goutil.WithRetryClock(clock), goutil.WithMaxElapsedTime(time.Hour))
+	}()
+
+	for i := 0; i < 60; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+
+	if err := <-errCh; !errors.Is(err, goutil.ErrTimeout) {
+		t.Errorf("RetryWithContext() error = %v, want %v", err, goutil.ErrTimeout)
+	}
+	if calls != 61 {
+		t.Errorf("RetryWithContext() called fn %d times, want 61", calls)
+	}
+}
+
+func TestRetryAfterHintWithinLimitsWithFakeClock(t *testing.T) {
+	var ErrThrottled = errors.New("throttled")
+
+	tests := []struct {
+		name    string
+		backoff goutil.Backoff
+		opts    []goutil.RetryOption
+	}{
+		{"max elapsed time", goutil.ConstantBackoff{Delay: time.Millisecond}, []goutil.RetryOption{goutil.WithMaxElapsedTime(10 * time.Millisecond)}},
+		{"max delay", goutil.MaxDelay(goutil.ConstantBackoff{Delay: time.Millisecond}, time.Minute), nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+
+			errCh := make(chan error, 1)
+			calls := 0
+			go func() {
+				errCh <- goutil.RetryWithContext(context.Background(), tt.backoff, func(context.Context) (bool, error) {
+					calls++
+					return false, goutil.RetryAfter(ErrThrottled, time.Hour)
+				}, append(tt.opts, goutil.WithRetryClock(clock))...)
+			}()
+
+			select {
+			case err := <-errCh:
+				var retryErr *goutil.RetryError
+				if !errors.As(err, &retryErr) || !errors.Is(err, goutil.ErrTimeout) || !errors.Is(err, ErrThrottled) {
+					t.Errorf("RetryWithContext() error = %v, want a *RetryError wrapping %v and %v", err, goutil.ErrTimeout, ErrThrottled)
+				}
+				if calls != 1 {
+					t.Errorf("RetryWithContext() called fn %d times, want 1", calls)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("RetryWithContext() waited for a Retry-After hint beyond the retry limits")
+			}
+		})
+	}
+}
+
+func TestRetryTransportMaxRetryAfterWithFakeClock(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantCalls  int32
+		wantCode   int
+	}{
+		{name: "within limit", retryAfter: "30", wantCalls: 2, wantCode: http.StatusOK},
+		{name: "beyond limit", retryAfter: "3600", wantCalls: 1, wantCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+			client := &http.Client{Transport: &goutil.RetryTransport{
+				Backoff:       goutil.ConstantBackoff{Delay: time.Millisecond},
+				MaxRetryAfter: time.Minute,
+				Options:       []goutil.RetryOption{goutil.WithRetryClock(clock)},
+			}}
+
+			respCh := make(chan *http.Response, 1)
+			errCh := make(chan error, 1)
+			go func() {
+				resp, err := client.Get(srv.URL)
+				respCh <- resp
+				errCh <- err
+			}()
+
+			if tt.wantCalls > 1 {
+				clock.BlockUntil(1)
+				clock.Advance(30 * time.Second)
+			}
+
+			var resp *http.Response
+			select {
+			case resp = <-respCh:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Get() waited for a Retry-After of %s seconds", tt.retryAfter)
+			}
+			if err := <-errCh; err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("Get() status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("Get() made %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestJitterSourceIsDeterministic(t *testing.T) {
+	a := &goutil.DecorrelatedJitterBackoff{Base: time.Second, Cap: time.Minute, Rand: goutil.NewJitterSource(42)}
+	b := &goutil.DecorrelatedJitterBackoff{Base: time.Second, Cap: time.Minute, Rand: goutil.NewJitterSource(42)}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		da, _ := a.NextDelay(attempt)
+		db, _ := b.NextDelay(attempt)
+		if da != db {
+			t.Fatalf("NextDelay(%d) = %v and %v, want equal delays for equal seeds", attempt, da, db)
+		}
+	}
+}
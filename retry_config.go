@@ -0,0 +1,293 @@
+package goutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetryPolicies is the registry of named retry policies used by the application,
+// preloaded with the presets "default", "fast", "unlimited" and "none".
+// Operators can tune it at runtime with LoadJSON, LoadYAML or LoadEnv.
+var RetryPolicies = NewRetryPolicyRegistry()
+
+// InvalidPolicyError reports a field of a retry policy with a nonsensical value.
+type InvalidPolicyError struct {
+	Policy string
+	Field  string
+	Reason string
+}
+
+func (e *InvalidPolicyError) Error() string {
+	if e.Policy == "" {
+		return fmt.Sprintf("invalid retry policy: %s %s", e.Field, e.Reason)
+	}
+
+	return fmt.Sprintf("invalid retry policy %q: %s %s", e.Policy, e.Field, e.Reason)
+}
+
+// Validate reports every field of b with a nonsensical value, as *InvalidPolicyError joined together.
+func (b BackoffWait) Validate() error {
+	return b.validate("")
+}
+
+func (b BackoffWait) validate(name string) error {
+	var errs []error
+	invalid := func(field, reason string) {
+		errs = append(errs, &InvalidPolicyError{Policy: name, Field: field, Reason: reason})
+	}
+
+	if b.TotalRuns < 1 {
+		invalid("total_runs", "must be at least 1")
+	}
+	if b.BaseDuration < 0 {
+		invalid("base_duration", "must not be negative")
+	}
+	for _, f := range []struct {
+		field string
+		value float64
+	}{{"factor", b.Factor}, {"jitter_factor", b.JitterFactor}} {
+		switch {
+		case math.IsNaN(f.value) || math.IsInf(f.value, 0):
+			invalid(f.field, "must be a finite number")
+		case f.value < 0:
+			invalid(f.field, "must not be negative")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backoffWaitJSON is the JSON form of a BackoffWait, with base_duration as a string like "250ms".
+type backoffWaitJSON struct {
+	TotalRuns    int             `json:"total_runs"`
+	BaseDuration json.RawMessage `json:"base_duration,omitempty"`
+	Factor       float64         `json:"factor"`
+	JitterFactor float64         `json:"jitter_factor"`
+}
+
+// MarshalJSON encodes BaseDuration as a duration string such as "1.5s".
+func (b BackoffWait) MarshalJSON() ([]byte, error) {
+	d, err := json.Marshal(b.BaseDuration.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(backoffWaitJSON{TotalRuns: b.TotalRuns, BaseDuration: d, Factor: b.Factor, JitterFactor: b.JitterFactor})
+}
+
+// UnmarshalJSON accepts base_duration as a duration string such as "250ms",
+// or as a number of nanoseconds as written by earlier versions.
+func (b *BackoffWait) UnmarshalJSON(data []byte) error {
+	var v backoffWaitJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	d, err := parseJSONDuration(v.BaseDuration)
+	if err != nil {
+		return fmt.Errorf("base_duration: %w", err)
+	}
+
+	b.TotalRuns, b.BaseDuration, b.Factor, b.JitterFactor = v.TotalRuns, d, v.Factor, v.JitterFactor
+
+	return nil
+}
+
+func parseJSONDuration(raw json.RawMessage) (time.Duration, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return time.ParseDuration(s)
+	}
+
+	var n int64
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, fmt.Errorf("invalid duration %s", raw)
+	}
+
+	return time.Duration(n), nil
+}
+
+// RetryPolicyRegistry holds named retry policies. It is safe for concurrent use.
+//
+// Policy names are case-insensitive, they are stored in lower case. This way the names of environment variables,
+// which are usually upper-cased, refer to the same policies as the names in JSON and YAML documents:
+// RETRY_PAYMENTS_TOTAL_RUNS sets the policy loaded from JSON as "payments" or "Payments".
+type RetryPolicyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]BackoffWait
+}
+
+// NewRetryPolicyRegistry returns a registry holding the presets "default", "fast", "unlimited" and "none".
+func NewRetryPolicyRegistry() *RetryPolicyRegistry {
+	return &RetryPolicyRegistry{policies: map[string]BackoffWait{
+		"default":   NewDefaultRetry(),
+		"fast":      NewFastRetry(),
+		"unlimited": NewUnlimitedRetry(),
+		"none":      NewNoRetry(),
+	}}
+}
+
+// Register validates policy and stores it under name, replacing any previous policy of that name.
+func (r *RetryPolicyRegistry) Register(name string, policy BackoffWait) error {
+	return r.registerAll(map[string]BackoffWait{name: policy})
+}
+
+// Get returns the policy registered under name.
+func (r *RetryPolicyRegistry) Get(name string) (BackoffWait, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, ok := r.policies[strings.ToLower(name)]
+	return policy, ok
+}
+
+// GetOr returns the policy registered under name, or fallback if there is none.
+func (r *RetryPolicyRegistry) GetOr(name string, fallback BackoffWait) BackoffWait {
+	if policy, ok := r.Get(name); ok {
+		return policy
+	}
+
+	return fallback
+}
+
+// Names returns the lower-cased names of the registered policies in alphabetical order.
+func (r *RetryPolicyRegistry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.policies))
+	for name := range r.policies {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+
+	return names
+}
+
+// LoadJSON reads a JSON object mapping names to policies, such as
+// {"payments": {"total_runs": 3, "base_duration": "250ms", "factor": 2}}, and registers them.
+// Nothing is registered unless every policy is valid.
+func (r *RetryPolicyRegistry) LoadJSON(rd io.Reader) error {
+	var policies map[string]BackoffWait
+	if err := json.NewDecoder(rd).Decode(&policies); err != nil {
+		return fmt.Errorf("decoding retry policies: %w", err)
+	}
+
+	return r.registerAll(policies)
+}
+
+// LoadYAML reads a YAML mapping of names to policies, such as
+//
+//	payments:
+//	  total_runs: 3
+//	  base_duration: 250ms
+//	  factor: 2
+//
+// and registers them. Nothing is registered unless every policy is valid.
+func (r *RetryPolicyRegistry) LoadYAML(rd io.Reader) error {
+	var policies map[string]BackoffWait
+	if err := yaml.NewDecoder(rd).Decode(&policies); err != nil {
+		return fmt.Errorf("decoding retry policies: %w", err)
+	}
+
+	return r.registerAll(policies)
+}
+
+// envFields maps the suffixes of the variables read by LoadEnv to the fields they set.
+var envFields = []struct {
+	suffix string
+	set    func(b *BackoffWait, value string) error
+}{
+	{"_TOTAL_RUNS", func(b *BackoffWait, v string) (err error) { b.TotalRuns, err = strconv.Atoi(v); return }},
+	{"_BASE_DURATION", func(b *BackoffWait, v string) (err error) { b.BaseDuration, err = time.ParseDuration(v); return }},
+	{"_JITTER_FACTOR", func(b *BackoffWait, v string) (err error) { b.JitterFactor, err = strconv.ParseFloat(v, 64); return }},
+	{"_FACTOR", func(b *BackoffWait, v string) (err error) { b.Factor, err = strconv.ParseFloat(v, 64); return }},
+}
+
+// LoadEnv reads policies from environment variables named prefix, the upper-cased policy name and a field,
+// for example RETRY_PAYMENTS_TOTAL_RUNS=3 and RETRY_PAYMENTS_BASE_DURATION=250ms with prefix "RETRY_".
+// Variables override the fields of an already registered policy.
+// Nothing is registered unless every policy is valid.
+func (r *RetryPolicyRegistry) LoadEnv(prefix string) error {
+	policies := make(map[string]BackoffWait)
+
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		for _, f := range envFields {
+			if !strings.HasSuffix(key, f.suffix) {
+				continue
+			}
+
+			name := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(key, prefix), f.suffix))
+			if name == "" {
+				break
+			}
+
+			policy, ok := policies[name]
+			if !ok {
+				policy, _ = r.Get(name)
+			}
+
+			if err := f.set(&policy, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			policies[name] = policy
+
+			break
+		}
+	}
+
+	return r.registerAll(policies)
+}
+
+// registerAll validates policies and registers them under their lower-cased names, or none of them if one is invalid.
+func (r *RetryPolicyRegistry) registerAll(policies map[string]BackoffWait) error {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	lower := make(map[string]BackoffWait, len(policies))
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if _, ok := lower[key]; ok {
+			errs = append(errs, fmt.Errorf("duplicate retry policy %q: names are case-insensitive", name))
+		}
+		lower[key] = policies[name]
+		errs = append(errs, policies[name].validate(name))
+	}
+	policies = lower
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, policy := range policies {
+		r.policies[name] = policy
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package goutil
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBackoffWaitJSON(t *testing.T) {
+	data, err := json.Marshal(BackoffWait{TotalRuns: 3, BaseDuration: 250 * time.Millisecond, Factor: 2, JitterFactor: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"total_runs":3,"base_duration":"250ms","factor":2,"jitter_factor":0.1}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	tests := []struct {
+		name string
+		json string
+		want time.Duration
+	}{
+		{name: "duration string", json: `{"total_runs":2,"base_duration":"1.5s"}`, want: 1500 * time.Millisecond},
+		{name: "nanoseconds", json: `{"total_runs":2,"base_duration":1000000}`, want: time.Millisecond},
+		{name: "missing", json: `{"total_runs":2}`, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b BackoffWait
+			if err := json.Unmarshal([]byte(tt.json), &b); err != nil {
+				t.Fatal(err)
+			}
+			if b.BaseDuration != tt.want || b.TotalRuns != 2 {
+				t.Errorf("json.Unmarshal() = %+v, want base duration %v", b, tt.want)
+			}
+		})
+	}
+
+	var b BackoffWait
+	if err := json.Unmarshal([]byte(`{"base_duration":"soon"}`), &b); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+}
+
+func TestBackoffWaitValidate(t *testing.T) {
+	for _, preset := range []BackoffWait{NewDefaultRetry(), NewFastRetry(), NewUnlimitedRetry(), NewNoRetry()} {
+		if err := preset.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", preset, err)
+		}
+	}
+
+	err := BackoffWait{TotalRuns: 0, BaseDuration: -time.Second, Factor: -1, JitterFactor: -0.5}.Validate()
+
+	var invalid *InvalidPolicyError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() = %v, want an *InvalidPolicyError", err)
+	}
+	for _, field := range []string{"total_runs", "base_duration", "factor", "jitter_factor"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected %q in %v", field, err)
+		}
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		err := BackoffWait{TotalRuns: 2, Factor: f, JitterFactor: f}.Validate()
+		if err == nil || !strings.Contains(err.Error(), "factor must be a finite number") || !strings.Contains(err.Error(), "jitter_factor") {
+			t.Errorf("Validate() with factors %v = %v, want both factors rejected", f, err)
+		}
+	}
+}
+
+func TestBackoffWaitYAML(t *testing.T) {
+	policies := map[string]BackoffWait{
+		"payments": {TotalRuns: 3, BaseDuration: 250 * time.Millisecond, Factor: 2, JitterFactor: 0.1},
+	}
+
+	data, err := yaml.Marshal(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "base_duration: 250ms") {
+		t.Errorf("yaml.Marshal() = %s, want a human duration", data)
+	}
+
+	r := NewRetryPolicyRegistry()
+	if err := r.LoadYAML(strings.NewReader(string(data))); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Get("payments"); got != policies["payments"] {
+		t.Errorf("Get(payments) = %+v after a YAML round trip, want %+v", got, policies["payments"])
+	}
+
+	err = r.LoadYAML(strings.NewReader("ok:\n  total_runs: 2\nbroken:\n  total_runs: 2\n  factor: .nan\n"))
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Errorf("LoadYAML() = %v, want an error naming the broken policy", err)
+	}
+	if _, ok := r.Get("ok"); ok {
+		t.Error("Expected LoadYAML to register nothing when a policy is invalid")
+	}
+}
+
+func TestRetryPolicyRegistry(t *testing.T) {
+	r := NewRetryPolicyRegistry()
+
+	if got, ok := r.Get("fast"); !ok || got != NewFastRetry() {
+		t.Errorf("Get(fast) = %+v, %v, want NewFastRetry", got, ok)
+	}
+	if got := r.GetOr("missing", NewNoRetry()); got != NewNoRetry() {
+		t.Errorf("GetOr(missing) = %+v, want NewNoRetry", got)
+	}
+
+	if err := r.Register("bad", BackoffWait{}); err == nil {
+		t.Error("Expected Register to reject an invalid policy")
+	}
+
+	err := r.LoadJSON(strings.NewReader(`{
+		"payments": {"total_runs": 4, "base_duration": "250ms", "factor": 1.5},
+		"default": {"total_runs": 3, "base_duration": "2s", "factor": 2}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := r.Get("payments"); got.TotalRuns != 4 || got.BaseDuration != 250*time.Millisecond || got.Factor != 1.5 {
+		t.Errorf("Get(payments) = %+v", got)
+	}
+	if got, _ := r.Get("default"); got.TotalRuns != 3 || got.BaseDuration != 2*time.Second {
+		t.Errorf("Get(default) = %+v", got)
+	}
+
+	err = r.LoadJSON(strings.NewReader(`{"ok": {"total_runs": 2}, "broken": {"total_runs": 0}}`))
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) {
+		t.Errorf("LoadJSON() = %v, want an error naming the broken policy", err)
+	}
+	if _, ok := r.Get("ok"); ok {
+		t.Error("Expected LoadJSON to register nothing when a policy is invalid")
+	}
+
+	want := []string{"default", "fast", "none", "payments", "unlimited"}
+	if got := r.Names(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestRetryPolicyRegistryLoadEnv(t *testing.T) {
+	t.Setenv("GOUTIL_TEST_RETRY_FAST_TOTAL_RUNS", "5")
+	t.Setenv("GOUTIL_TEST_RETRY_SLOW_API_TOTAL_RUNS", "3")
+	t.Setenv("GOUTIL_TEST_RETRY_SLOW_API_BASE_DURATION", "2s")
+	t.Setenv("GOUTIL_TEST_RETRY_SLOW_API_JITTER_FACTOR", "0.2")
+
+	r := NewRetryPolicyRegistry()
+	if err := r.LoadEnv("GOUTIL_TEST_RETRY_"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := r.Get("fast"); got.TotalRuns != 5 || got.BaseDuration != NewFastRetry().BaseDuration {
+		t.Errorf("Expected the env to override only total runs of fast, got %+v", got)
+	}
+	if got, _ := r.Get("slow_api"); got.TotalRuns != 3 || got.BaseDuration != 2*time.Second || got.JitterFactor != 0.2 || got.Factor != 0 {
+		t.Errorf("Get(slow_api) = %+v", got)
+	}
+
+	t.Setenv("GOUTIL_TEST_RETRY_FAST_BASE_DURATION", "-1s")
+	if err := r.LoadEnv("GOUTIL_TEST_RETRY_"); err == nil {
+		t.Error("Expected LoadEnv to reject a negative duration")
+	}
+
+	t.Setenv("GOUTIL_TEST_RETRY_FAST_BASE_DURATION", "1s")
+	t.Setenv("GOUTIL_TEST_RETRY_FAST_FACTOR", "NaN")
+	if err := r.LoadEnv("GOUTIL_TEST_RETRY_"); err == nil {
+		t.Error("Expected LoadEnv to reject a NaN factor")
+	}
+}
+
+func TestRetryPolicyRegistryCaseInsensitive(t *testing.T) {
+	t.Setenv("GOUTIL_TEST_CASE_PAYMENTS_TOTAL_RUNS", "7")
+
+	r := NewRetryPolicyRegistry()
+	if err := r.LoadJSON(strings.NewReader(`{"Payments": {"total_runs": 3, "base_duration": "1s"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadEnv("GOUTIL_TEST_CASE_"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"payments", "Payments", "PAYMENTS"} {
+		if got, ok := r.Get(name); !ok || got.TotalRuns != 7 || got.BaseDuration != time.Second {
+			t.Errorf("Get(%s) = %+v, %v, want the JSON policy with the total runs of the env", name, got, ok)
+		}
+	}
+
+	err := r.LoadJSON(strings.NewReader(`{"orders": {"total_runs": 2}, "Orders": {"total_runs": 3}}`))
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("LoadJSON() = %v, want an error for names differing only in case", err)
+	}
+}
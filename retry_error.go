@@ -0,0 +1,98 @@
+package goutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// RetryAttempt records a call made by the retry helpers that did not succeed.
+// Delay is the time waited before the next call, zero for the last one.
+type RetryAttempt struct {
+	Err   error
+	Time  time.Time
+	Delay time.Duration
+}
+
+// RetryError is returned by the retry helpers when they give up, and records every attempt.
+// errors.Is and errors.As match the errors of the attempts, most recent first,
+// and ErrTimeout if the last attempt returned no error or the context was done.
+// Formatting a RetryError with %+v prints one line per attempt after the summary.
+type RetryError struct {
+	Attempts []RetryAttempt
+
+	cause error
+}
+
+// Last returns the error of the last attempt, which may be nil.
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+func (e *RetryError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "giving up after %d attempt", len(e.Attempts))
+	if len(e.Attempts) != 1 {
+		b.WriteString("s")
+	}
+
+	last := e.Last()
+	switch {
+	case e.cause != nil && last != nil:
+		fmt.Fprintf(&b, ": %v, last error: %v", e.cause, last)
+	case e.cause != nil:
+		fmt.Fprintf(&b, ": %v", e.cause)
+	case last != nil:
+		fmt.Fprintf(&b, ": %v", last)
+	}
+
+	return b.String()
+}
+
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts)+1)
+	for i := len(e.Attempts) - 1; i >= 0; i-- {
+		if e.Attempts[i].Err != nil {
+			errs = append(errs, e.Attempts[i].Err)
+		}
+	}
+
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+
+	return errs
+}
+
+// Format implements fmt.Formatter, %+v prints every attempt.
+func (e *RetryError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		io.WriteString(s, e.Error())
+		if !s.Flag('+') {
+			return
+		}
+
+		for i, a := range e.Attempts {
+			fmt.Fprintf(s, "\n\tattempt %d at %s: ", i+1, a.Time.Format(time.RFC3339Nano))
+			if a.Err != nil {
+				fmt.Fprintf(s, "%v", a.Err)
+			} else {
+				io.WriteString(s, "not done")
+			}
+			if a.Delay > 0 {
+				fmt.Fprintf(s, ", retrying in %v", a.Delay)
+			}
+		}
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
@@ -0,0 +1,76 @@
+package goutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codeError struct {
+	code int
+}
+
+func (e *codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+func TestRetryErrorRecordsAttempts(t *testing.T) {
+	backoff := BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond, Factor: 2}
+
+	calls := 0
+	err := RetryWithExponentialBackoff(backoff, func() (bool, error) {
+		calls++
+		return false, &codeError{code: calls}
+	})
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected a *RetryError, got %T", err)
+	}
+	if len(retryErr.Attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(retryErr.Attempts))
+	}
+	if retryErr.Attempts[0].Delay != time.Millisecond || retryErr.Attempts[1].Delay != 2*time.Millisecond || retryErr.Attempts[2].Delay != 0 {
+		t.Errorf("Unexpected delays: %v, %v, %v", retryErr.Attempts[0].Delay, retryErr.Attempts[1].Delay, retryErr.Attempts[2].Delay)
+	}
+	if !retryErr.Attempts[0].Time.Before(retryErr.Attempts[2].Time) {
+		t.Errorf("Expected attempt timestamps to increase")
+	}
+
+	var ce *codeError
+	if !errors.As(err, &ce) || ce.code != 3 {
+		t.Errorf("Expected errors.As to find the most recent error, got %v", ce)
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected %v not to match ErrTimeout when the last attempt failed", err)
+	}
+
+	if want := "giving up after 3 attempts: code 3"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	summary := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"attempt 1 at", "code 1, retrying in 1ms", "code 2, retrying in 2ms", "attempt 3 at"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in summary:\n%s", want, summary)
+		}
+	}
+}
+
+func TestRetryErrorTimeout(t *testing.T) {
+	err := RetryWithExponentialBackoff(BackoffWait{TotalRuns: 2, BaseDuration: time.Millisecond}, func() (bool, error) {
+		return false, nil
+	})
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected %v to match ErrTimeout", err)
+	}
+	if want := "giving up after 2 attempts: timed out waiting for the condition"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if summary := fmt.Sprintf("%+v", err); !strings.Contains(summary, "not done") {
+		t.Errorf("Expected unfinished attempts in summary:\n%s", summary)
+	}
+}
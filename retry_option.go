@@ -0,0 +1,185 @@
+package goutil
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryOption configures the retry helpers.
+type RetryOption func(*retryOptions)
+
+type retryOptions struct {
+	isPermanent    func(error) bool
+	retryAfterF    func(error) (time.Duration, bool)
+	maxElapsedTime time.Duration
+	clock          Clock
+	onRetry        func(attempt int, err error, nextDelay time.Duration)
+	onGiveUp       func(attempts int, err error)
+	onSuccess      func(attempts int)
+	budget         *RetryBudget
+	breaker        *CircuitBreaker
+}
+
+func newRetryOptions(opts []RetryOption) *retryOptions {
+	o := &retryOptions{retryAfterF: retryAfterHint, clock: RealClock{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithPermanentClassifier sets a function that reports whether an error can never succeed on retry.
+// Errors wrapped with Permanent are always treated as permanent.
+func WithPermanentClassifier(isPermanent func(error) bool) RetryOption {
+	return func(o *retryOptions) {
+		o.isPermanent = isPermanent
+	}
+}
+
+// WithRetryAfter sets the function that extracts a delay hint from an error, overriding the next backoff delay.
+// By default the delay of a RetryAfterError in the error chain is used. A hint beyond MaxDelay or the WithMaxElapsedTime
+// limit stops the retries instead of waiting.
+func WithRetryAfter(retryAfter func(error) (time.Duration, bool)) RetryOption {
+	return func(o *retryOptions) {
+		o.retryAfterF = retryAfter
+	}
+}
+
+// WithMaxElapsedTime stops retrying once the next attempt would start more than d after the first one.
+// It only applies when the context has no deadline, a deadline always takes precedence. Zero means no limit.
+func WithMaxElapsedTime(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.maxElapsedTime = d
+	}
+}
+
+// WithRetryClock sets the Clock used to time attempts and wait between them. It defaults to RealClock.
+// goutiltest.FakeClock makes retries run instantly in tests.
+func WithRetryClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.clock = clock
+	}
+}
+
+// OnRetry sets a hook called after every failed attempt that will be retried,
+// with the number of the attempt, its error and the delay before the next one.
+func OnRetry(hook func(attempt int, err error, nextDelay time.Duration)) RetryOption {
+	return func(o *retryOptions) {
+		o.onRetry = hook
+	}
+}
+
+// OnGiveUp sets a hook called with the number of attempts made and the returned error when the retries fail.
+func OnGiveUp(hook func(attempts int, err error)) RetryOption {
+	return func(o *retryOptions) {
+		o.onGiveUp = hook
+	}
+}
+
+// OnSuccess sets a hook called with the number of attempts made when the retried function succeeds.
+func OnSuccess(hook func(attempts int)) RetryOption {
+	return func(o *retryOptions) {
+		o.onSuccess = hook
+	}
+}
+
+// WithRetryBudget makes every retry spend a token of budget, and every success deposit to it.
+// When the budget is empty the retries stop with a *RetryError wrapping ErrRetryBudgetExhausted.
+func WithRetryBudget(budget *RetryBudget) RetryOption {
+	return func(o *retryOptions) {
+		o.budget = budget
+	}
+}
+
+// WithCircuitBreaker makes every attempt go through breaker. When the breaker rejects an attempt,
+// the retries stop immediately with a *RetryError wrapping ErrCircuitOpen.
+func WithCircuitBreaker(breaker *CircuitBreaker) RetryOption {
+	return func(o *retryOptions) {
+		o.breaker = breaker
+	}
+}
+
+func (o *retryOptions) permanent(err error) bool {
+	var pe *PermanentError
+	if errors.As(err, &pe) {
+		return true
+	}
+
+	return o.isPermanent != nil && o.isPermanent(err)
+}
+
+func (o *retryOptions) retryAfter(err error) (time.Duration, bool) {
+	if err == nil || o.retryAfterF == nil {
+		return 0, false
+	}
+
+	return o.retryAfterF(err)
+}
+
+// PermanentError wraps an error that retrying cannot fix, such as bad input or an authentication failure.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent marks err as permanent so that the retry helpers return it without further attempts.
+// Permanent(nil) returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// unwrapPermanent returns the error wrapped by a top level PermanentError.
+func unwrapPermanent(err error) error {
+	if pe, ok := err.(*PermanentError); ok {
+		return pe.Err
+	}
+
+	return err
+}
+
+// RetryAfterError wraps an error with a hint of how long to wait before the next attempt,
+// such as the Retry-After header of an HTTP response.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// RetryAfter wraps err with a hint to wait delay before the next attempt instead of the backoff delay.
+// RetryAfter(nil, delay) returns nil.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// retryAfterHint returns the delay of the first RetryAfterError in the chain of err.
+func retryAfterHint(err error) (time.Duration, bool) {
+	var rae *RetryAfterError
+	if errors.As(err, &rae) {
+		return rae.Delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,170 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryWithPermanentError(t *testing.T) {
+	var ErrBadInput = errors.New("bad input")
+
+	tests := []struct {
+		name      string
+		err       error
+		opts      []RetryOption
+		wantCalls int
+	}{
+		{name: "transient error is retried", err: ErrBadInput, wantCalls: 3},
+		{name: "permanent error stops retries", err: Permanent(ErrBadInput), wantCalls: 1},
+		{
+			name:      "classifier marks error as permanent",
+			err:       ErrBadInput,
+			opts:      []RetryOption{WithPermanentClassifier(func(err error) bool { return errors.Is(err, ErrBadInput) })},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			backoff := BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond}
+			err := RetryWithExponentialBackoff(backoff, func() (bool, error) {
+				calls++
+				return false, tt.err
+			}, tt.opts...)
+
+			if !errors.Is(err, ErrBadInput) {
+				t.Errorf("RetryWithExponentialBackoff() error = %v, want %v", err, ErrBadInput)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("RetryWithExponentialBackoff() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryWithRetryAfterHint(t *testing.T) {
+	var ErrThrottled = errors.New("throttled")
+	backoff := BackoffWait{TotalRuns: 2, BaseDuration: time.Hour}
+
+	start := time.Now()
+	calls := 0
+	err := RetryWithExponentialBackoff(backoff, func() (bool, error) {
+		calls++
+		if calls == 1 {
+			return false, RetryAfter(ErrThrottled, time.Millisecond)
+		}
+		return true, nil
+	})
+
+	if err != nil {
+		t.Errorf("RetryWithExponentialBackoff() error = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("Expected the retry-after hint to override the backoff, waited %v", elapsed)
+	}
+
+	custom := WithRetryAfter(func(err error) (time.Duration, bool) {
+		return time.Millisecond, errors.Is(err, ErrThrottled)
+	})
+	calls = 0
+	err = RetryWithExponentialBackoff(backoff, func() (bool, error) {
+		calls++
+		return calls > 1, ErrThrottled
+	}, custom)
+
+	if err != ErrThrottled {
+		t.Errorf("RetryWithExponentialBackoff() error = %v, want %v", err, ErrThrottled)
+	}
+	if calls != 2 {
+		t.Errorf("RetryWithExponentialBackoff() called fn %d times, want 2", calls)
+	}
+}
+
+func TestPermanentAndRetryAfterNil(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+	if RetryAfter(nil, time.Second) != nil {
+		t.Error("RetryAfter(nil) should be nil")
+	}
+
+	wrapped := RetryAfter(Permanent(ErrTimeout), time.Second)
+	if !errors.Is(wrapped, ErrTimeout) {
+		t.Errorf("Expected %v to wrap %v", wrapped, ErrTimeout)
+	}
+}
+
+func TestRetryHooks(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	backoff := BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond}
+
+	var retries []int
+	var gaveUp, succeeded int
+	hooks := []RetryOption{
+		OnRetry(func(attempt int, err error, nextDelay time.Duration) {
+			if err != ErrCustom || nextDelay != time.Millisecond {
+				t.Errorf("OnRetry(%d, %v, %v), want error %v and delay 1ms", attempt, err, nextDelay, ErrCustom)
+			}
+			retries = append(retries, attempt)
+		}),
+		OnGiveUp(func(attempts int, err error) { gaveUp = attempts }),
+		OnSuccess(func(attempts int) { succeeded = attempts }),
+	}
+
+	_ = RetryWithExponentialBackoff(backoff, func() (bool, error) { return false, ErrCustom }, hooks...)
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("Expected OnRetry for attempts 1 and 2, got %v", retries)
+	}
+	if gaveUp != 3 || succeeded != 0 {
+		t.Errorf("Expected OnGiveUp after 3 attempts and no OnSuccess, got %d and %d", gaveUp, succeeded)
+	}
+
+	retries, gaveUp = nil, 0
+	calls := 0
+	_ = RetryWithExponentialBackoff(backoff, func() (bool, error) {
+		calls++
+		if calls < 2 {
+			return false, ErrCustom
+		}
+		return true, nil
+	}, hooks...)
+	if len(retries) != 1 || gaveUp != 0 || succeeded != 2 {
+		t.Errorf("Expected 1 retry and OnSuccess after 2 attempts, got %v, %d, %d", retries, gaveUp, succeeded)
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+
+	if _, ok := AttemptFromContext(context.Background()); ok {
+		t.Error("Expected no Attempt outside of a retry")
+	}
+
+	var attempts []Attempt
+	_ = RetryWithContext(context.Background(), BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond}, func(ctx context.Context) (bool, error) {
+		a, ok := AttemptFromContext(ctx)
+		if !ok {
+			t.Fatal("Expected an Attempt in the context")
+		}
+		attempts = append(attempts, a)
+		return false, ErrCustom
+	})
+
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+	}
+	for i, a := range attempts {
+		if a.Number != i+1 {
+			t.Errorf("attempt %d has Number %d", i+1, a.Number)
+		}
+		if (i == 0) != (a.PrevErr == nil) {
+			t.Errorf("attempt %d has PrevErr %v", i+1, a.PrevErr)
+		}
+	}
+	if attempts[0].Elapsed != 0 || attempts[2].Elapsed < 2*time.Millisecond {
+		t.Errorf("Unexpected elapsed times %v and %v", attempts[0].Elapsed, attempts[2].Elapsed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -13,10 +14,10 @@ func TestRetryTimeDuration(t *testing.T) {
 		name  string
 		retry BackoffWait
 	}{
-		{name: "NoRetry", retry: NoRetry},
-		{name: "DefaultRetry", retry: DefaultRetry},
-		{name: "FastRetry", retry: FastRetry},
-		{name: "UnlimitedRetry", retry: UnlimitedRetry},
+		{name: "NoRetry", retry: NewNoRetry()},
+		{name: "DefaultRetry", retry: NewDefaultRetry()},
+		{name: "FastRetry", retry: NewFastRetry()},
+		{name: "UnlimitedRetry", retry: NewUnlimitedRetry()},
 	}
 
 	for _, r := range retries {
@@ -26,8 +27,9 @@ func TestRetryTimeDuration(t *testing.T) {
 
 		t.Run(r.name, func(t *testing.T) {
 			fmt.Printf("Retry strategy: %s\n", r.name)
-			for i := 0; r.retry.TotalRuns >= 2; i++ {
-				fmt.Printf("ran %d times. next run wait duration: %v\n", i+1, r.retry.wait())
+			state := NewBackoffState(r.retry)
+			for delay, ok := state.Next(); ok; delay, ok = state.Next() {
+				fmt.Printf("ran %d times. next run wait duration: %v\n", state.Attempts(), delay)
 			}
 			fmt.Println()
 		})
@@ -35,6 +37,11 @@ func TestRetryTimeDuration(t *testing.T) {
 }
 
 func TestRetryWithExponentialBackoff(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	// The retries of NewDefaultRetry, a millisecond apart instead of a second, so that the test does not sleep.
+	quickRetry := NewDefaultRetry()
+	quickRetry.BaseDuration = time.Millisecond
+
 	tests := []struct {
 		name        string
 		backoff     BackoffWait
@@ -44,24 +51,24 @@ func TestRetryWithExponentialBackoff(t *testing.T) {
 	}{
 		{
 			name:        "retry succeeds",
-			backoff:     DefaultRetry,
+			backoff:     quickRetry,
 			fn:          func() (bool, error) { return true, nil },
 			wantErr:     false,
 			expectedErr: nil,
 		},
 		{
 			name:        "retry exceeds limit",
-			backoff:     NoRetry,
+			backoff:     NewNoRetry(),
 			fn:          func() (bool, error) { return false, nil },
 			wantErr:     true,
 			expectedErr: ErrTimeout,
 		},
 		{
 			name:        "function returns error",
-			backoff:     DefaultRetry,
-			fn:          func() (bool, error) { return false, errors.New("custom error") },
+			backoff:     quickRetry,
+			fn:          func() (bool, error) { return false, ErrCustom },
 			wantErr:     true,
-			expectedErr: errors.New("custom error"),
+			expectedErr: ErrCustom,
 		},
 	}
 
@@ -73,7 +80,7 @@ func TestRetryWithExponentialBackoff(t *testing.T) {
 				t.Errorf("RetryWithExponentialBackoff() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			if err != nil && err.Error() != tt.expectedErr.Error() {
+			if err != nil && !errors.Is(err, tt.expectedErr) {
 				t.Errorf("RetryWithExponentialBackoff() got = %v, want %v", err, tt.expectedErr)
 			}
 		})
@@ -81,7 +88,9 @@ func TestRetryWithExponentialBackoff(t *testing.T) {
 }
 
 func TestRetryWithExponentialBackoffUntilTimeout(t *testing.T) {
-	timeoutCtx, _ := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	var ErrCustom = errors.New("custom error")
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
 	tests := []struct {
 		name        string
 		ctx         context.Context
@@ -106,9 +115,9 @@ func TestRetryWithExponentialBackoffUntilTimeout(t *testing.T) {
 		{
 			name:        "return custom error",
 			ctx:         timeoutCtx,
-			fn:          func(context.Context) (bool, error) { return false, errors.New("custom error") },
+			fn:          func(context.Context) (bool, error) { return false, ErrCustom },
 			wantErr:     true,
-			expectedErr: errors.New("custom error"),
+			expectedErr: ErrCustom,
 		},
 		{
 			name:        "function returns error",
@@ -134,7 +143,7 @@ func TestRetryWithExponentialBackoffUntilTimeout(t *testing.T) {
 				t.Errorf("RetryWithExponentialBackoffUntilTimeout() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			if err != nil && err.Error() != tt.expectedErr.Error() {
+			if err != nil && !errors.Is(err, tt.expectedErr) {
 				t.Errorf("RetryWithExponentialBackoffUntilTimeout() got = %v, want %v", err, tt.expectedErr)
 			}
 		})
@@ -158,15 +167,90 @@ func TestBackoffWait(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := tt.backoff.wait()
-			if tt.name == "total runs less than 1" && got != tt.backoff.BaseDuration {
-				t.Errorf("BackoffWait.wait() = %v, want %v", got, tt.backoff.BaseDuration)
-			}
-			if tt.name == "total runs less than 1 with jitter" && (got <= tt.backoff.BaseDuration || got > tt.backoff.BaseDuration+time.Duration(float64(tt.backoff.BaseDuration)*tt.backoff.JitterFactor)) {
-				t.Errorf("BackoffWait.wait() with jitter = %v, want in range (%v, %v)", got, tt.backoff.BaseDuration, tt.backoff.BaseDuration+time.Duration(float64(tt.backoff.BaseDuration)*tt.backoff.JitterFactor))
+			if got, ok := tt.backoff.NextDelay(1); ok {
+				t.Errorf("BackoffWait.NextDelay(1) = %v, %v, want no retry", got, ok)
 			}
 		})
 	}
+
+	backoff := BackoffWait{TotalRuns: 3, BaseDuration: time.Second, Factor: 2.0, JitterFactor: 0.1}
+	for i := 0; i < 2; i++ {
+		got, ok := backoff.NextDelay(2)
+		if !ok || got < 2*time.Second || got > 2*time.Second+200*time.Millisecond {
+			t.Errorf("BackoffWait.NextDelay(2) with jitter = %v, %v, want in range [%v, %v]", got, ok, 2*time.Second, 2*time.Second+200*time.Millisecond)
+		}
+	}
+	if backoff.TotalRuns != 3 || backoff.BaseDuration != time.Second {
+		t.Errorf("Expected NextDelay to leave the BackoffWait unchanged, got %+v", backoff)
+	}
+}
+
+func TestBackoffWaitZeroTotalRuns(t *testing.T) {
+	for _, totalRuns := range []int{0, -1} {
+		calls := 0
+		err := RetryWithExponentialBackoff(BackoffWait{TotalRuns: totalRuns, BaseDuration: time.Hour}, func() (bool, error) {
+			calls++
+			return false, nil
+		})
+
+		if calls != 1 {
+			t.Errorf("TotalRuns %d: fn called %d times, want 1", totalRuns, calls)
+		}
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("TotalRuns %d: RetryWithExponentialBackoff() = %v, want ErrTimeout", totalRuns, err)
+		}
+	}
+}
+
+func TestRetryPresetsAreImmutable(t *testing.T) {
+	preset := NewDefaultRetry()
+	preset.TotalRuns = 100
+	preset.BaseDuration = time.Hour
+
+	if got := NewDefaultRetry(); got.TotalRuns != 2 || got.BaseDuration != time.Second {
+		t.Errorf("NewDefaultRetry() = %+v after changing a copy, want the original preset", got)
+	}
+}
+
+func TestDeprecatedRetryPresets(t *testing.T) {
+	presets := []struct {
+		name      string
+		got, want BackoffWait
+	}{
+		{name: "DefaultRetry", got: DefaultRetry, want: NewDefaultRetry()},
+		{name: "FastRetry", got: FastRetry, want: NewFastRetry()},
+		{name: "UnlimitedRetry", got: UnlimitedRetry, want: NewUnlimitedRetry()},
+		{name: "NoRetry", got: NoRetry, want: NewNoRetry()},
+	}
+
+	for _, p := range presets {
+		if p.got != p.want {
+			t.Errorf("%s = %+v, want %+v", p.name, p.got, p.want)
+		}
+	}
+}
+
+func TestRetrySharedBackoffWait(t *testing.T) {
+	backoff := NewFastRetry()
+	backoff.BaseDuration = time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			attempts := 0
+			_ = RetryWithExponentialBackoff(backoff, func() (bool, error) {
+				attempts++
+				return false, errors.New("always")
+			})
+			if attempts != 2 {
+				t.Errorf("Expected 2 attempts for every retry sharing the policy, got %d", attempts)
+			}
+		}()
+	}
+	wg.Wait()
 }
 
 func TestAddJitter(t *testing.T) {
@@ -185,7 +269,7 @@ func TestAddJitter(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := addJitter(tt.args.duration, tt.args.factor)
+			got := addJitter(nil, tt.args.duration, tt.args.factor)
 			if Float64Equal(tt.args.factor, 0.0) {
 				tt.args.factor = 1.0
 			}
@@ -291,3 +375,159 @@ func TestExponentialBackoffWithCtx(t *testing.T) {
 		})
 	}
 }
+
+func TestRetry(t *testing.T) {
+	var ErrCustom = errors.New("custom error")
+	policy := BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond, Factor: 1.5}
+
+	tests := []struct {
+		name      string
+		failures  int
+		want      int
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "first call succeeds", failures: 0, want: 42, wantErr: nil, wantCalls: 1},
+		{name: "succeeds after retries", failures: 2, want: 42, wantErr: nil, wantCalls: 3},
+		{name: "retries exhausted", failures: 3, want: 0, wantErr: ErrCustom, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := Retry(context.Background(), policy, func(context.Context) (int, error) {
+				calls++
+				if calls <= tt.failures {
+					return -1, ErrCustom
+				}
+				return 42, nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Retry() = %v, want %v", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Retry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := Retry(ctx, NewDefaultRetry(), func(context.Context) (string, error) { return "unreachable", nil })
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Retry() error = %v, want %v", err, ErrTimeout)
+	}
+	if got != "" {
+		t.Errorf("Retry() = %q, want zero value", got)
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	backoff := BackoffWait{TotalRuns: 2, BaseDuration: time.Hour}
+
+	start := time.Now()
+	err := exponentialBackoffWithCtx(ctx, backoff, func(context.Context) (bool, error) {
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return false, nil
+	})
+
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("exponentialBackoffWithCtx() error = %v, want ErrTimeout wrapping context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to interrupt the backoff, waited %v", elapsed)
+	}
+}
+
+func TestRetryDoesNotSleepPastDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	backoff := BackoffWait{TotalRuns: 2, BaseDuration: time.Hour}
+
+	start := time.Now()
+	calls := 0
+	err := exponentialBackoffWithCtx(ctx, backoff, func(context.Context) (bool, error) {
+		calls++
+		return false, nil
+	})
+
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("exponentialBackoffWithCtx() error = %v, want ErrTimeout wrapping context.DeadlineExceeded", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected no wait beyond the deadline, waited %v", elapsed)
+	}
+}
+
+func TestRetryWithContext(t *testing.T) {
+	backoff := ConstantBackoff{Delay: 5 * time.Millisecond}
+
+	t.Run("no deadline uses max elapsed time", func(t *testing.T) {
+		err := RetryWithContext(context.Background(), backoff, func(context.Context) (bool, error) {
+			return false, nil
+		}, WithMaxElapsedTime(20*time.Millisecond))
+
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("RetryWithContext() error = %v, want %v", err, ErrTimeout)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := RetryWithContext(ctx, backoff, func(context.Context) (bool, error) {
+			return false, nil
+		})
+
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RetryWithContext() error = %v, want ErrTimeout wrapping context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("succeeds", func(t *testing.T) {
+		calls := 0
+		err := RetryWithContext(context.Background(), backoff, func(context.Context) (bool, error) {
+			calls++
+			return calls == 3, nil
+		})
+
+		if err != nil || calls != 3 {
+			t.Errorf("RetryWithContext() error = %v after %d calls, want nil after 3", err, calls)
+		}
+	})
+}
+
+func TestRetryWithContextSharedBackoff(t *testing.T) {
+	shared := MaxRetries(&DecorrelatedJitterBackoff{Base: time.Millisecond, Cap: 2 * time.Millisecond}, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			calls := 0
+			err := RetryWithContext(context.Background(), shared, func(context.Context) (bool, error) {
+				calls++
+				return false, nil
+			})
+
+			if !errors.Is(err, ErrTimeout) || calls != 4 {
+				t.Errorf("RetryWithContext() error = %v after %d calls, want ErrTimeout after 4", err, calls)
+			}
+		}()
+	}
+	wg.Wait()
+}
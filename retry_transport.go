@@ -0,0 +1,189 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryStatusCodes are the response status codes retried by a RetryTransport with no RetryStatusCodes.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// HTTPStatusError is the error recorded for an attempt of a RetryTransport that got a retryable status code.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "retryable response status: " + e.Status
+}
+
+// DefaultMaxRetryAfter is the longest Retry-After delay waited for by a RetryTransport with no MaxRetryAfter.
+const DefaultMaxRetryAfter = time.Minute
+
+// maxDrainBytes is how much of a discarded response body is read so that its connection can be reused.
+const maxDrainBytes = 64 << 10
+
+// RetryTransport is an http.RoundTripper that retries requests with a goutil retry policy.
+// It retries when Base returns an error, such as a refused connection, and when the response has one of
+// RetryStatusCodes. The delay of a Retry-After response header replaces the backoff delay.
+//
+// Retries stop when the request context is done. A request with a body is only retried if it has GetBody,
+// which http.NewRequest sets for the usual body types. Once the retries are exhausted on a retryable status,
+// the next delay would pass the deadline of the request, or a Retry-After header asks to wait longer than
+// MaxRetryAfter, the last response is returned with a nil error, as it would be without the RetryTransport.
+type RetryTransport struct {
+	// Base makes the requests. It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Backoff decides the delays between attempts. It defaults to NewDefaultRetry.
+	Backoff Backoff
+	// RetryStatusCodes are the response status codes that are retried. They default to DefaultRetryStatusCodes.
+	RetryStatusCodes []int
+	// MaxRetryAfter is the longest Retry-After delay that is waited for. It defaults to DefaultMaxRetryAfter.
+	MaxRetryAfter time.Duration
+	// Options are passed to RetryWithContext for every request.
+	Options []RetryOption
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return base.RoundTrip(req)
+	}
+
+	backoff := t.Backoff
+	if backoff == nil {
+		backoff = NewDefaultRetry()
+	}
+
+	maxRetryAfter := t.MaxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = DefaultMaxRetryAfter
+	}
+	clock := newRetryOptions(t.Options).clock
+
+	var resp *http.Response
+	err := RetryWithContext(req.Context(), backoff, func(ctx context.Context) (bool, error) {
+		if resp != nil {
+			discardResponse(resp)
+			resp = nil
+		}
+
+		r, err := req, error(nil)
+		if attempt, _ := AttemptFromContext(ctx); attempt.Number > 1 {
+			if r, err = rewindRequest(req); err != nil {
+				return false, Permanent(err)
+			}
+		}
+
+		resp, err = base.RoundTrip(r)
+		if err != nil {
+			resp = nil
+			return false, err
+		}
+
+		if !t.retryStatus(resp.StatusCode) {
+			return true, nil
+		}
+
+		err = &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), clock.Now()); ok {
+			if delay > maxRetryAfter {
+				return false, Permanent(err)
+			}
+			err = RetryAfter(err, delay)
+		}
+
+		return false, err
+	}, t.Options...)
+
+	if err == nil {
+		return resp, nil
+	}
+
+	if resp != nil {
+		var statusErr *HTTPStatusError
+		if req.Context().Err() == nil && errors.As(err, &statusErr) {
+			return resp, nil
+		}
+		discardResponse(resp)
+	}
+
+	return nil, err
+}
+
+func (t *RetryTransport) retryStatus(code int) bool {
+	codes := t.RetryStatusCodes
+	if codes == nil {
+		codes = DefaultRetryStatusCodes
+	}
+
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewindRequest returns a copy of req with a fresh body from GetBody, to send it again.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rewinding request body: %w", err)
+		}
+		r.Body = body
+	}
+
+	return r, nil
+}
+
+// discardResponse reads a little of the body of resp and closes it, so that its connection can be reused.
+func discardResponse(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	_ = resp.Body.Close()
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(maxDuration/time.Second) {
+			return maxDuration, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,202 @@
+package goutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantCode  int
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, wantCalls: 1, wantCode: http.StatusOK},
+		{name: "retry until success", statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, wantCalls: 3, wantCode: http.StatusOK},
+		{name: "not retryable", statuses: []int{http.StatusInternalServerError, http.StatusOK}, wantCalls: 1, wantCode: http.StatusInternalServerError},
+		{name: "exhausted", statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, wantCalls: 3, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.statuses[n-1])
+				_, _ = io.WriteString(w, "body")
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &RetryTransport{
+				Backoff: BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond},
+			}}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantCode || string(body) != "body" {
+				t.Errorf("Get() = %d %q, want %d %q", resp.StatusCode, body, tt.wantCode, "body")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRewindsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &RetryTransport{
+		Backoff: BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond},
+	}}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if strings.Join(bodies, ",") != "payload,payload,payload" {
+		t.Errorf("Expected the body to be sent on every attempt, got %q", bodies)
+	}
+
+	// A body without GetBody cannot be rewound, so the request is sent once.
+	bodies = nil
+	req, _ := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("once")))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || len(bodies) != 1 {
+		t.Errorf("Expected one attempt with status 503, got %d attempts with status %d", len(bodies), resp.StatusCode)
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := &http.Client{Transport: &RetryTransport{
+		Backoff: BackoffWait{TotalRuns: 2, BaseDuration: time.Minute},
+		Options: []RetryOption{OnRetry(func(attempt int, err error, nextDelay time.Duration) {
+			var statusErr *HTTPStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("OnRetry() error = %v, want an *HTTPStatusError with status 429", err)
+			}
+			delays = append(delays, nextDelay)
+		})},
+	}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || len(delays) != 1 || delays[0] != 0 {
+		t.Errorf("Get() = %d with delays %v, want 200 after waiting 0s", resp.StatusCode, delays)
+	}
+}
+
+func TestRetryTransportConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	attempts := 0
+	client := &http.Client{Transport: &RetryTransport{
+		Backoff: BackoffWait{TotalRuns: 3, BaseDuration: time.Millisecond},
+		Options: []RetryOption{OnGiveUp(func(n int, err error) { attempts = n })},
+	}}
+
+	_, err := client.Get(url)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || attempts != 3 {
+		t.Errorf("Get() error = %v after %d attempts, want a *RetryError after 3", err, attempts)
+	}
+}
+
+func TestRetryTransportContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &RetryTransport{
+		Backoff: ConstantBackoff{Delay: 10 * time.Millisecond},
+	}}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Do() error = %v, want %v", err, ErrTimeout)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected no attempt after the context was cancelled, got %d", n)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "120", want: 2 * time.Minute, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "soon", wantOK: false},
+		{value: "Mon, 01 Jan 2024 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Mon, 01 Jan 2024 11:00:00 GMT", want: 0, wantOK: true},
+		{value: "99999999999999999", want: maxDuration, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
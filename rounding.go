@@ -0,0 +1,148 @@
+package goutil
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// RoundingMode selects how a number that falls between two representable results is rounded.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest result, and ties to the even one: 2.5 -> 2, 3.5 -> 4.
+	// It is the default of IEEE 754 and is also known as banker's rounding.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest result, and ties away from zero: 2.5 -> 3, -2.5 -> -3.
+	RoundHalfUp
+	// RoundHalfDown rounds to the nearest result, and ties toward zero: 2.5 -> 2, -2.5 -> -2.
+	RoundHalfDown
+	// RoundUp rounds away from zero: 2.1 -> 3, -2.1 -> -3.
+	RoundUp
+	// RoundDown rounds toward zero, truncating: 2.9 -> 2, -2.9 -> -2.
+	RoundDown
+	// RoundCeiling rounds toward positive infinity: 2.1 -> 3, -2.9 -> -2.
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity: 2.9 -> 2, -2.1 -> -3.
+	RoundFloor
+)
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundHalfDown:
+		return "half-down"
+	case RoundUp:
+		return "up"
+	case RoundDown:
+		return "down"
+	case RoundCeiling:
+		return "ceiling"
+	case RoundFloor:
+		return "floor"
+	default:
+		return "unknown"
+	}
+}
+
+// RoundFloat64 rounds f to places digits after the decimal point with mode. A negative places rounds
+// to tens, hundreds and so on.
+//
+// f is rounded as the shortest decimal that converts back to it, the number it is printed as,
+// rather than as its exact binary value: RoundFloat64(2.675, 2, RoundHalfUp) is 2.68 even though
+// the float64 nearest to 2.675 is slightly below it. NaN and infinities are returned unchanged,
+// a result of zero keeps the sign of f, and a result beyond the range of float64 is an infinity.
+func RoundFloat64(f float64, places int, mode RoundingMode) float64 {
+	d, ok := shortestDecimal(f)
+	if !ok || d.Scale() <= places {
+		return f
+	}
+
+	rounded, _ := strconv.ParseFloat(d.Round(places, mode).String(), 64)
+	if rounded == 0 {
+		return math.Copysign(0, f)
+	}
+
+	return rounded
+}
+
+// FormatFloat64 formats f with exactly places digits after the decimal point, rounded with mode like RoundFloat64,
+// and without an exponent: FormatFloat64(1.005, 2, RoundHalfUp) is "1.01".
+// Like with RoundFloat64, a negative places rounds to tens, hundreds and so on, and the result has no decimal point:
+// FormatFloat64(1250, -2, RoundHalfEven) is "1200".
+// Zero is formatted without a sign, NaN and infinities as "NaN", "+Inf" and "-Inf".
+func FormatFloat64(f float64, places int, mode RoundingMode) string {
+	d, ok := shortestDecimal(f)
+	if !ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return d.Round(places, mode).String()
+}
+
+// FormatFloat64Short formats f with at most 15 significant digits, the precision that a float64 keeps for
+// any decimal number, and no trailing zeros. It hides the artifacts of binary arithmetic:
+// FormatFloat64Short(0.1 + 0.2) is "0.3", where strconv gives "0.30000000000000004".
+// Very large and very small magnitudes use an exponent, such as "1e+21".
+func FormatFloat64Short(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+
+	return strconv.FormatFloat(f, 'g', 15, 64)
+}
+
+// shortestDecimal returns the shortest decimal that converts back to f. It returns false for NaN and infinities.
+func shortestDecimal(f float64) (Decimal, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, false
+	}
+
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+
+	return d, err == nil
+}
+
+// roundUp reports whether a quotient truncated toward zero must be moved one step away from zero
+// under mode. sign is the sign of the exact quotient, odd whether the truncated quotient is odd,
+// and half compares the discarded remainder with half of the divisor. The remainder must not be zero.
+func (m RoundingMode) roundUp(sign int, odd bool, half int) bool {
+	switch m {
+	case RoundHalfEven:
+		return half > 0 || half == 0 && odd
+	case RoundHalfUp:
+		return half >= 0
+	case RoundHalfDown:
+		return half > 0
+	case RoundUp:
+		return true
+	case RoundCeiling:
+		return sign > 0
+	case RoundFloor:
+		return sign < 0
+	default:
+		return false
+	}
+}
+
+// roundQuo returns n / d rounded with mode. d must not be zero.
+func roundQuo(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	sign := n.Sign() * d.Sign()
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(d))
+
+	if mode.roundUp(sign, q.Bit(0) == 1, half) {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+
+	return q
+}
@@ -0,0 +1,165 @@
+package goutil
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestRoundQuo(t *testing.T) {
+	// Each row divides n by 10, so the inputs read as 2.5, -2.5 and so on.
+	tests := []struct {
+		n    int64
+		want map[RoundingMode]int64
+	}{
+		{n: 25, want: map[RoundingMode]int64{RoundHalfEven: 2, RoundHalfUp: 3, RoundHalfDown: 2, RoundUp: 3, RoundDown: 2, RoundCeiling: 3, RoundFloor: 2}},
+		{n: 35, want: map[RoundingMode]int64{RoundHalfEven: 4, RoundHalfUp: 4, RoundHalfDown: 3, RoundUp: 4, RoundDown: 3, RoundCeiling: 4, RoundFloor: 3}},
+		{n: -25, want: map[RoundingMode]int64{RoundHalfEven: -2, RoundHalfUp: -3, RoundHalfDown: -2, RoundUp: -3, RoundDown: -2, RoundCeiling: -2, RoundFloor: -3}},
+		{n: -35, want: map[RoundingMode]int64{RoundHalfEven: -4, RoundHalfUp: -4, RoundHalfDown: -3, RoundUp: -4, RoundDown: -3, RoundCeiling: -3, RoundFloor: -4}},
+		{n: 21, want: map[RoundingMode]int64{RoundHalfEven: 2, RoundHalfUp: 2, RoundHalfDown: 2, RoundUp: 3, RoundDown: 2, RoundCeiling: 3, RoundFloor: 2}},
+		{n: -29, want: map[RoundingMode]int64{RoundHalfEven: -3, RoundHalfUp: -3, RoundHalfDown: -3, RoundUp: -3, RoundDown: -2, RoundCeiling: -2, RoundFloor: -3}},
+		{n: 30, want: map[RoundingMode]int64{RoundHalfEven: 3, RoundHalfUp: 3, RoundHalfDown: 3, RoundUp: 3, RoundDown: 3, RoundCeiling: 3, RoundFloor: 3}},
+		{n: 5, want: map[RoundingMode]int64{RoundHalfEven: 0, RoundHalfUp: 1, RoundHalfDown: 0, RoundUp: 1, RoundDown: 0, RoundCeiling: 1, RoundFloor: 0}},
+	}
+
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			if got := roundQuo(big.NewInt(tt.n), big.NewInt(10), mode); got.Int64() != want {
+				t.Errorf("roundQuo(%d, 10, %v) = %v, want %d", tt.n, mode, got, want)
+			}
+			// A negative divisor flips the sign of the exact quotient.
+			if got := roundQuo(big.NewInt(-tt.n), big.NewInt(-10), mode); got.Int64() != want {
+				t.Errorf("roundQuo(%d, -10, %v) = %v, want %d", -tt.n, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestRoundingModeString(t *testing.T) {
+	if got := RoundHalfEven.String(); got != "half-even" {
+		t.Errorf("RoundHalfEven.String() = %q, want %q", got, "half-even")
+	}
+	if got := RoundingMode(99).String(); got != "unknown" {
+		t.Errorf("RoundingMode(99).String() = %q, want %q", got, "unknown")
+	}
+}
+
+func TestRoundFloat64(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+
+	tests := []struct {
+		f      float64
+		places int
+		mode   RoundingMode
+		want   float64
+	}{
+		// Values whose nearest float64 lies below the decimal they are written as.
+		{f: 2.675, places: 2, mode: RoundHalfUp, want: 2.68},
+		{f: 1.005, places: 2, mode: RoundHalfUp, want: 1.01},
+		{f: 0.285, places: 2, mode: RoundHalfUp, want: 0.29},
+		{f: 1.255, places: 2, mode: RoundHalfEven, want: 1.26},
+		{f: 1.245, places: 2, mode: RoundHalfEven, want: 1.24},
+		{f: 0.1 + 0.2, places: 2, mode: RoundHalfEven, want: 0.3},
+		{f: 2.5, places: 0, mode: RoundHalfEven, want: 2},
+		{f: 3.5, places: 0, mode: RoundHalfEven, want: 4},
+		{f: -2.5, places: 0, mode: RoundHalfUp, want: -3},
+		{f: -2.5, places: 0, mode: RoundHalfDown, want: -2},
+		{f: 1.234, places: 2, mode: RoundCeiling, want: 1.24},
+		{f: -1.234, places: 2, mode: RoundCeiling, want: -1.23},
+		{f: 1.239, places: 2, mode: RoundFloor, want: 1.23},
+		{f: -1.231, places: 2, mode: RoundFloor, want: -1.24},
+		{f: 1.231, places: 2, mode: RoundUp, want: 1.24},
+		{f: -1.239, places: 2, mode: RoundDown, want: -1.23},
+		{f: 1250, places: -2, mode: RoundHalfEven, want: 1200},
+		{f: 1351, places: -2, mode: RoundFloor, want: 1300},
+		{f: 1.5, places: 5, mode: RoundHalfEven, want: 1.5},
+		{f: 1e300, places: 2, mode: RoundHalfEven, want: 1e300},
+		{f: 123456789012345680000, places: -5, mode: RoundHalfEven, want: 123456789012345700000},
+		{f: 9007199254740993, places: 0, mode: RoundHalfEven, want: 9007199254740993},
+		{f: 5e-324, places: 2, mode: RoundHalfEven, want: 0},
+		{f: 5e-324, places: 2, mode: RoundUp, want: 0.01},
+		{f: -0.001, places: 2, mode: RoundHalfEven, want: negZero},
+		{f: math.MaxFloat64, places: -308, mode: RoundUp, want: math.Inf(1)},
+		{f: math.Inf(-1), places: 2, mode: RoundHalfEven, want: math.Inf(-1)},
+	}
+
+	for _, tt := range tests {
+		got := RoundFloat64(tt.f, tt.places, tt.mode)
+		if got != tt.want || math.Signbit(got) != math.Signbit(tt.want) {
+			t.Errorf("RoundFloat64(%v, %d, %v) = %v, want %v", tt.f, tt.places, tt.mode, got, tt.want)
+		}
+	}
+
+	if got := RoundFloat64(math.NaN(), 2, RoundHalfEven); !math.IsNaN(got) {
+		t.Errorf("RoundFloat64(NaN) = %v, want NaN", got)
+	}
+}
+
+func TestFormatFloat64(t *testing.T) {
+	tests := []struct {
+		f      float64
+		places int
+		mode   RoundingMode
+		want   string
+	}{
+		{f: 1.005, places: 2, mode: RoundHalfUp, want: "1.01"},
+		{f: 0.1 + 0.2, places: 2, mode: RoundHalfEven, want: "0.30"},
+		{f: 2, places: 3, mode: RoundHalfEven, want: "2.000"},
+		{f: -0.001, places: 2, mode: RoundHalfEven, want: "0.00"},
+		{f: -1.5, places: 0, mode: RoundHalfEven, want: "-2"},
+		{f: 1e21, places: 1, mode: RoundHalfEven, want: "1000000000000000000000.0"},
+		{f: 1.5e-7, places: 8, mode: RoundHalfEven, want: "0.00000015"},
+		{f: 1234.5, places: -2, mode: RoundHalfEven, want: "1200"},
+		{f: 1250, places: -2, mode: RoundHalfEven, want: "1200"},
+		{f: -1250, places: -2, mode: RoundHalfUp, want: "-1300"},
+		{f: 45, places: -2, mode: RoundHalfEven, want: "0"},
+		{f: math.NaN(), places: 2, mode: RoundHalfEven, want: "NaN"},
+		{f: math.Inf(1), places: 2, mode: RoundHalfEven, want: "+Inf"},
+		{f: math.Inf(-1), places: 2, mode: RoundHalfEven, want: "-Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFloat64(tt.f, tt.places, tt.mode); got != tt.want {
+			t.Errorf("FormatFloat64(%v, %d, %v) = %q, want %q", tt.f, tt.places, tt.mode, got, tt.want)
+		}
+
+		// FormatFloat64 and RoundFloat64 agree on every places, including negative ones.
+		if rounded := RoundFloat64(tt.f, tt.places, tt.mode); FormatFloat64Short(rounded) != FormatFloat64Short(mustParseFloat(t, tt.want)) {
+			t.Errorf("RoundFloat64(%v, %d, %v) = %v, want %s like FormatFloat64", tt.f, tt.places, tt.mode, rounded, tt.want)
+		}
+	}
+}
+
+func mustParseFloat(t *testing.T, s string) float64 {
+	t.Helper()
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestFormatFloat64Short(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want string
+	}{
+		{f: 0.1 + 0.2, want: "0.3"},
+		{f: 1.1 * 1.1, want: "1.21"},
+		{f: 100, want: "100"},
+		{f: -2.5, want: "-2.5"},
+		{f: math.Copysign(0, -1), want: "0"},
+		{f: 1e21, want: "1e+21"},
+		{f: 123456.789, want: "123456.789"},
+		{f: math.Inf(-1), want: "-Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFloat64Short(tt.f); got != tt.want {
+			t.Errorf("FormatFloat64Short(%v) = %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package goutil
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScheduleOption configures a function scheduled by GoAfter or GoEvery.
+type ScheduleOption func(*scheduleOptions)
+
+type scheduleOptions struct {
+	clock        Clock
+	jitterFactor float64
+	jitterSource JitterSource
+	noOverlap    bool
+	errorHandler func(err interface{})
+}
+
+// WithScheduleClock sets the Clock used to wait between runs. It defaults to RealClock.
+func WithScheduleClock(clock Clock) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.clock = clock
+	}
+}
+
+// WithScheduleJitter adds a random delay of up to jitterFactor times the interval before every run.
+func WithScheduleJitter(jitterFactor float64) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.jitterFactor = jitterFactor
+	}
+}
+
+// WithScheduleJitterSource sets the source of the jitter added by WithScheduleJitter.
+func WithScheduleJitterSource(src JitterSource) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.jitterSource = src
+	}
+}
+
+// WithoutOverlap skips a run of GoEvery if the previous one has not returned yet.
+func WithoutOverlap() ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.noOverlap = true
+	}
+}
+
+// WithScheduleErrorHandler sets the handler of panics raised by the scheduled function.
+// It defaults to the handler used by Go.
+func WithScheduleErrorHandler(errorHandler func(err interface{})) ScheduleOption {
+	return func(o *scheduleOptions) {
+		o.errorHandler = errorHandler
+	}
+}
+
+// ScheduledTask is a handle to a function scheduled by GoAfter or GoEvery.
+type ScheduledTask struct {
+	opts scheduleOptions
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+	running  int32
+}
+
+// GoAfter runs fn in its own goroutine with recovery capability once delay has elapsed,
+// unless the returned task is stopped first.
+func GoAfter(delay time.Duration, fn func(), opts ...ScheduleOption) *ScheduledTask {
+	t := newScheduledTask(opts)
+	go t.loop(context.Background(), func() time.Duration { return delay }, false, fn)
+
+	return t
+}
+
+// GoEvery runs fn in its own goroutine with recovery capability every interval
+// until ctx is done or the returned task is stopped.
+// A panic in one run is passed to the error handler and does not end the schedule.
+// GoEvery panics if interval is not positive.
+func GoEvery(ctx context.Context, interval time.Duration, fn func(), opts ...ScheduleOption) *ScheduledTask {
+	if interval <= 0 {
+		panic("goutil: non-positive interval for GoEvery")
+	}
+
+	t := newScheduledTask(opts)
+	go t.loop(ctx, func() time.Duration {
+		if t.opts.jitterFactor > 0 {
+			return addJitter(t.opts.jitterSource, interval, t.opts.jitterFactor)
+		}
+		return interval
+	}, true, fn)
+
+	return t
+}
+
+func newScheduledTask(opts []ScheduleOption) *ScheduledTask {
+	t := &ScheduledTask{
+		opts: scheduleOptions{
+			clock:        RealClock{},
+			errorHandler: defaultPanicLimiter.Handler(defaultErrorHandler),
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+
+	return t
+}
+
+// Stop cancels all future runs. A run that has already started is not interrupted.
+func (t *ScheduledTask) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+}
+
+// Done returns a channel that is closed once the task is stopped and every started run has returned.
+func (t *ScheduledTask) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *ScheduledTask) loop(ctx context.Context, next func() time.Duration, repeat bool, fn func()) {
+	defer func() {
+		t.wg.Wait()
+		close(t.done)
+	}()
+
+	for {
+		timer := t.opts.clock.NewTimer(next())
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-t.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		t.run(fn)
+
+		if !repeat {
+			return
+		}
+	}
+}
+
+func (t *ScheduledTask) run(fn func()) {
+	if t.opts.noOverlap && !atomic.CompareAndSwapInt32(&t.running, 0, 1) {
+		return
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		if t.opts.noOverlap {
+			defer atomic.StoreInt32(&t.running, 0)
+		}
+
+		runWithErrorHandler(fn, t.opts.errorHandler)
+	}()
+}
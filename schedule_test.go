@@ -0,0 +1,131 @@
+package goutil_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qcrao/goutil"
+	"github.com/qcrao/goutil/goutiltest"
+)
+
+func TestGoEvery(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 10)
+
+	task := goutil.GoEvery(ctx, time.Second, func() { runs <- struct{}{} }, goutil.WithScheduleClock(clock))
+
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		<-runs
+	}
+
+	cancel()
+	<-task.Done()
+
+	if len(runs) != 0 {
+		t.Errorf("Expected no extra runs, got %d", len(runs))
+	}
+}
+
+func TestGoEveryRecoversPanics(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	panics := make(chan interface{}, 10)
+
+	task := goutil.GoEvery(context.Background(), time.Second, func() { panic("tick") },
+		goutil.WithScheduleClock(clock),
+		goutil.WithScheduleErrorHandler(func(err interface{}) { panics <- err }))
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		if err := <-panics; err != "tick" {
+			t.Errorf("Expected 'tick', got '%v'", err)
+		}
+	}
+
+	task.Stop()
+	<-task.Done()
+}
+
+func TestGoEveryWithoutOverlap(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	task := goutil.GoEvery(context.Background(), time.Second, func() {
+		started <- struct{}{}
+		<-release
+	}, goutil.WithScheduleClock(clock), goutil.WithoutOverlap())
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	<-started
+
+	// The second tick arrives while the first run is still blocked and must be skipped.
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1)
+
+	close(release)
+	task.Stop()
+	<-task.Done()
+
+	if len(started) != 0 {
+		t.Errorf("Expected the overlapping run to be skipped, got %d extra runs", len(started))
+	}
+}
+
+func TestGoEveryWithJitter(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	runs := make(chan struct{}, 10)
+
+	task := goutil.GoEvery(context.Background(), time.Second, func() { runs <- struct{}{} },
+		goutil.WithScheduleClock(clock), goutil.WithScheduleJitter(0.5))
+
+	for i := 0; i < 5; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(1500 * time.Millisecond)
+		<-runs
+	}
+
+	task.Stop()
+	<-task.Done()
+}
+
+func TestGoAfter(t *testing.T) {
+	clock := goutiltest.NewFakeClock(time.Unix(0, 0))
+	ran := make(chan struct{}, 1)
+
+	task := goutil.GoAfter(time.Second, func() { ran <- struct{}{} }, goutil.WithScheduleClock(clock))
+
+	clock.BlockUntil(1)
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ran:
+		t.Fatal("GoAfter ran before its delay")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	<-task.Done()
+
+	if len(ran) != 1 {
+		t.Errorf("Expected GoAfter to run once, got %d", len(ran))
+	}
+}
+
+func TestGoAfterStop(t *testing.T) {
+	task := goutil.GoAfter(time.Hour, func() { t.Error("Stopped task ran") })
+
+	task.Stop()
+	task.Stop()
+
+	select {
+	case <-task.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected stopped task to be done")
+	}
+}
@@ -9,7 +9,7 @@ type Foo struct {
 	Baz int
 }
 
-func ExampleXXDump() {
+func ExampleDump() {
 	foo := Foo{
 		Bar: "Hello, World!",
 		Baz: 123,
@@ -22,12 +22,6 @@ func ExampleXXDump() {
 	//  Baz: (int) 123
 	//}
 
-	Sdump(foo)
-	// Output:
-	//(goutil.Foo) {
-	//  Bar: (string) (len=13) "Hello, World!",
-	//  Baz: (int) 123
-	//}
-
+	_ = Sdump(foo)
 	Fdump(io.Discard, foo)
 }
@@ -0,0 +1,342 @@
+package goutil
+
+import "math"
+
+// Summary accumulates the count, mean, variance, minimum and maximum of a stream of values
+// in constant memory, with Welford's algorithm. The zero value is an empty Summary.
+//
+// A Summary is a BatchResult: the Summary of every batch can be merged into the Summary of all of them.
+// It is not safe for concurrent use.
+type Summary struct {
+	n        int64
+	mean     float64
+	m2       float64 // sum of squared differences from the mean
+	min, max float64
+}
+
+// Add adds x to the summary. NaN values are ignored.
+func (s *Summary) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+
+	s.n++
+	if s.n == 1 {
+		s.mean, s.m2, s.min, s.max = x, 0, x, x
+		return
+	}
+
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+	s.min = math.Min(s.min, x)
+	s.max = math.Max(s.max, x)
+}
+
+// Merge adds the values of another *Summary to s, as if they had been added to s one by one.
+func (s *Summary) Merge(other BatchResult) {
+	o, ok := other.(*Summary)
+	if !ok || o.n == 0 {
+		return
+	}
+	if s.n == 0 {
+		*s = *o
+		return
+	}
+
+	// Chan et al.'s formula for combining the variance of two samples.
+	n := s.n + o.n
+	delta := o.mean - s.mean
+	s.m2 += o.m2 + delta*delta*float64(s.n)*float64(o.n)/float64(n)
+	s.mean += delta * float64(o.n) / float64(n)
+	s.n = n
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+}
+
+// Count returns the number of values added.
+func (s *Summary) Count() int64 {
+	return s.n
+}
+
+// Mean returns the arithmetic mean of the values, or NaN if there are none.
+func (s *Summary) Mean() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+
+	return s.mean
+}
+
+// Variance returns the sample variance of the values, or NaN if there are fewer than two.
+func (s *Summary) Variance() float64 {
+	if s.n < 2 {
+		return math.NaN()
+	}
+
+	return s.m2 / float64(s.n-1)
+}
+
+// StdDev returns the sample standard deviation of the values, or NaN if there are fewer than two.
+func (s *Summary) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Min returns the smallest value, or NaN if there are none.
+func (s *Summary) Min() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+
+	return s.min
+}
+
+// Max returns the largest value, or NaN if there are none.
+func (s *Summary) Max() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+
+	return s.max
+}
+
+// EWMA is an exponentially weighted moving average: every new value x moves the average
+// by alpha * (x - average), so older values weigh less and less. It is not safe for concurrent use.
+type EWMA struct {
+	alpha float64
+	value float64
+	init  bool
+}
+
+// NewEWMA returns an empty EWMA. A larger alpha follows recent values more closely;
+// alpha = 2/(N+1) approximates a moving average of the last N values.
+// NewEWMA panics if alpha is not in (0, 1].
+func NewEWMA(alpha float64) *EWMA {
+	if !(alpha > 0 && alpha <= 1) {
+		panic("goutil: EWMA alpha must be in (0, 1]")
+	}
+
+	return &EWMA{alpha: alpha}
+}
+
+// Add adds x to the average. The first value sets the average. NaN values are ignored.
+func (e *EWMA) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+
+	if !e.init {
+		e.value, e.init = x, true
+		return
+	}
+
+	e.value += e.alpha * (x - e.value)
+}
+
+// Value returns the current average, or NaN if no value was added.
+func (e *EWMA) Value() float64 {
+	if !e.init {
+		return math.NaN()
+	}
+
+	return e.value
+}
+
+// defaultSketchBins is the number of bins of a QuantileSketch created with maxBins <= 0. With a relative accuracy
+// of 1%, it covers about 17 orders of magnitude without collapsing, such as latencies from 1ns to 3 years.
+const defaultSketchBins = 2048
+
+// QuantileSketch estimates quantiles of a stream of values in bounded memory. It is a DDSketch:
+// every estimated quantile is within the relative accuracy of the true value, for example within 1% of it.
+// Values are counted in logarithmic bins, and once there are more than maxBins, the bins of the values
+// closest to zero are merged, so that the high quantiles, such as the latency p99, stay accurate.
+//
+// A QuantileSketch is a BatchResult: the sketches of every batch can be merged into one.
+// It is not safe for concurrent use.
+type QuantileSketch struct {
+	gamma    float64
+	logGamma float64
+	maxBins  int
+
+	positive sketchStore
+	negative sketchStore // indexed by the magnitude of the values
+	zeros    uint64
+	min, max float64
+}
+
+// NewQuantileSketch returns an empty QuantileSketch with the given relative accuracy, such as 0.01 for 1%,
+// using at most maxBins bins for the positive values and as many for the negative ones.
+// A maxBins <= 0 selects a default of 2048. NewQuantileSketch panics if relativeAccuracy is not in (0, 1).
+func NewQuantileSketch(relativeAccuracy float64, maxBins int) *QuantileSketch {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		panic("goutil: QuantileSketch relative accuracy must be in (0, 1)")
+	}
+	if maxBins <= 0 {
+		maxBins = defaultSketchBins
+	}
+
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+
+	return &QuantileSketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+// Add adds x to the sketch. NaN and infinite values are ignored.
+func (s *QuantileSketch) Add(x float64) {
+	s.addN(x, 1)
+}
+
+func (s *QuantileSketch) addN(x float64, n uint64) {
+	if math.IsNaN(x) || math.IsInf(x, 0) || n == 0 {
+		return
+	}
+
+	switch {
+	case x > 0:
+		s.positive.add(s.index(x), n, s.maxBins)
+	case x < 0:
+		s.negative.add(s.index(-x), n, s.maxBins)
+	default:
+		s.zeros += n
+	}
+
+	s.min = math.Min(s.min, x)
+	s.max = math.Max(s.max, x)
+}
+
+// Count returns the number of values added.
+func (s *QuantileSketch) Count() uint64 {
+	return s.negative.count + s.zeros + s.positive.count
+}
+
+// Quantile returns an estimate of the q-quantile of the values, for example Quantile(0.99) for the p99.
+// Quantile(0) and Quantile(1) are the exact minimum and maximum.
+// It returns NaN if the sketch is empty or q is not in [0, 1].
+func (s *QuantileSketch) Quantile(q float64) float64 {
+	count := s.Count()
+	switch {
+	case count == 0 || !(q >= 0 && q <= 1):
+		return math.NaN()
+	case q == 0:
+		return s.min
+	case q == 1:
+		return s.max
+	}
+
+	rank := uint64(q * float64(count-1))
+
+	var value float64
+	switch {
+	case rank < s.negative.count:
+		// The negative values are in bins of their magnitude, so the smallest value is in the highest bin.
+		value = -s.value(s.negative.indexAtRank(s.negative.count - 1 - rank))
+	case rank < s.negative.count+s.zeros:
+		value = 0
+	default:
+		value = s.value(s.positive.indexAtRank(rank - s.negative.count - s.zeros))
+	}
+
+	// The exact extremes are known, and are better estimates near them.
+	return math.Max(s.min, math.Min(s.max, value))
+}
+
+// Merge adds the values of another *QuantileSketch to s. Sketches with different accuracies can be merged,
+// the values of the other sketch are then counted at the center of their bins.
+func (s *QuantileSketch) Merge(other BatchResult) {
+	o, ok := other.(*QuantileSketch)
+	if !ok || o.Count() == 0 {
+		return
+	}
+
+	if o.gamma == s.gamma {
+		o.positive.each(func(index int, n uint64) { s.positive.add(index, n, s.maxBins) })
+		o.negative.each(func(index int, n uint64) { s.negative.add(index, n, s.maxBins) })
+	} else {
+		o.positive.each(func(index int, n uint64) { s.addN(o.value(index), n) })
+		o.negative.each(func(index int, n uint64) { s.addN(-o.value(index), n) })
+	}
+
+	s.zeros += o.zeros
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+}
+
+// index returns the bin of the positive value x, such that gamma^(index-1) < x <= gamma^index.
+func (s *QuantileSketch) index(x float64) int {
+	return int(math.Ceil(math.Log(x) / s.logGamma))
+}
+
+// value returns the value within the relative accuracy of every value in the bin index.
+func (s *QuantileSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// sketchStore counts values in contiguous bins, bins[i] being the count of the bin offset+i.
+type sketchStore struct {
+	bins   []uint64
+	offset int
+	count  uint64
+}
+
+// add counts n values in the bin index. If that would need more than maxBins bins,
+// the lowest bins are merged into the lowest one that is kept.
+func (s *sketchStore) add(index int, n uint64, maxBins int) {
+	if len(s.bins) == 0 {
+		s.bins, s.offset = []uint64{0}, index
+	}
+
+	lo, hi := s.offset, s.offset+len(s.bins)-1
+	if index < lo {
+		lo = index
+	}
+	if index > hi {
+		hi = index
+	}
+	if hi-lo+1 > maxBins {
+		lo = hi - maxBins + 1
+	}
+	if index < lo {
+		index = lo
+	}
+
+	if lo != s.offset || hi != s.offset+len(s.bins)-1 {
+		bins := make([]uint64, hi-lo+1)
+		for i, c := range s.bins {
+			j := s.offset + i - lo
+			if j < 0 {
+				j = 0
+			}
+			bins[j] += c
+		}
+		s.bins, s.offset = bins, lo
+	}
+
+	s.bins[index-s.offset] += n
+	s.count += n
+}
+
+// indexAtRank returns the bin of the value of the given rank, counting from 0 in increasing bin order.
+func (s *sketchStore) indexAtRank(rank uint64) int {
+	var seen uint64
+	for i, c := range s.bins {
+		seen += c
+		if seen > rank {
+			return s.offset + i
+		}
+	}
+
+	return s.offset + len(s.bins) - 1
+}
+
+func (s *sketchStore) each(fn func(index int, n uint64)) {
+	for i, c := range s.bins {
+		if c > 0 {
+			fn(s.offset+i, c)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package goutil
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	var s Summary
+	for _, v := range values {
+		s.Add(v)
+	}
+	s.Add(math.NaN())
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "Count", got: float64(s.Count()), want: 8},
+		{name: "Mean", got: s.Mean(), want: 5},
+		{name: "Variance", got: s.Variance(), want: 32.0 / 7},
+		{name: "StdDev", got: s.StdDev(), want: math.Sqrt(32.0 / 7)},
+		{name: "Min", got: s.Min(), want: 2},
+		{name: "Max", got: s.Max(), want: 9},
+	}
+
+	for _, tt := range tests {
+		if !Float64IsClose(tt.got, tt.want, 1e-12, 0) {
+			t.Errorf("Summary.%s() = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	var empty Summary
+	if !math.IsNaN(empty.Mean()) || !math.IsNaN(empty.Min()) || !math.IsNaN(empty.Max()) || !math.IsNaN(empty.Variance()) {
+		t.Error("Expected an empty Summary to report NaN")
+	}
+
+	var one Summary
+	one.Add(3)
+	if one.Mean() != 3 || !math.IsNaN(one.Variance()) {
+		t.Errorf("Expected mean 3 and no variance for a single value, got %v and %v", one.Mean(), one.Variance())
+	}
+}
+
+func TestSummaryStability(t *testing.T) {
+	// A large offset ruins the naive sum-of-squares formula, not Welford's.
+	var s Summary
+	for _, v := range []float64{4, 7, 13, 16} {
+		s.Add(1e9 + v)
+	}
+
+	if got := s.Variance(); !Float64IsClose(got, 30, 1e-9, 0) {
+		t.Errorf("Variance() = %v, want 30", got)
+	}
+}
+
+func TestSummaryMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	var whole, left, right Summary
+	for i := 0; i < 1000; i++ {
+		v := rng.NormFloat64()*10 + 100
+		whole.Add(v)
+		if i < 300 {
+			left.Add(v)
+		} else {
+			right.Add(v)
+		}
+	}
+
+	var merged Summary
+	merged.Merge(&left)
+	merged.Merge(&right)
+	merged.Merge(&Summary{})
+	merged.Merge(&SumResult{Sum: 1})
+
+	if equal, diff := ApproxEqual(merged, whole, 1e-9); !equal {
+		t.Errorf("Expected the merged summary to equal the whole one: %s", diff)
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	e := NewEWMA(0.5)
+	if !math.IsNaN(e.Value()) {
+		t.Errorf("Value() = %v for an empty EWMA, want NaN", e.Value())
+	}
+
+	for _, v := range []float64{10, 20, math.NaN(), 20, 0} {
+		e.Add(v)
+	}
+
+	// 10, then 15, then 17.5, then 8.75.
+	if got := e.Value(); got != 8.75 {
+		t.Errorf("Value() = %v, want 8.75", got)
+	}
+
+	for _, alpha := range []float64{0, -1, 1.5, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected NewEWMA(%v) to panic", alpha)
+				}
+			}()
+			NewEWMA(alpha)
+		}()
+	}
+}
+
+// exactQuantile returns the q-quantile of sorted with the rank used by QuantileSketch.
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestQuantileSketch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const accuracy = 0.01
+
+	tests := []struct {
+		name     string
+		generate func() float64
+	}{
+		{name: "latencies", generate: func() float64 { return math.Exp(rng.NormFloat64()) * 0.1 }},
+		{name: "uniform", generate: func() float64 { return rng.Float64() * 1000 }},
+		{name: "signed", generate: func() float64 { return rng.NormFloat64() * 100 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewQuantileSketch(accuracy, 0)
+			values := make([]float64, 10000)
+			for i := range values {
+				values[i] = tt.generate()
+				s.Add(values[i])
+			}
+			sort.Float64s(values)
+
+			if s.Count() != uint64(len(values)) {
+				t.Errorf("Count() = %d, want %d", s.Count(), len(values))
+			}
+
+			for _, q := range []float64{0, 0.1, 0.5, 0.9, 0.99, 0.999, 1} {
+				want := exactQuantile(values, q)
+				if got := s.Quantile(q); !Float64IsClose(got, want, accuracy, 0) {
+					t.Errorf("Quantile(%v) = %v, want %v within %v", q, got, want, accuracy)
+				}
+			}
+		})
+	}
+
+	empty := NewQuantileSketch(accuracy, 0)
+	if !math.IsNaN(empty.Quantile(0.5)) {
+		t.Error("Expected an empty sketch to return NaN")
+	}
+
+	empty.Add(0)
+	empty.Add(math.Inf(1))
+	empty.Add(math.NaN())
+	if empty.Count() != 1 || empty.Quantile(0.5) != 0 || !math.IsNaN(empty.Quantile(1.5)) {
+		t.Errorf("Expected a single zero, got count %d and median %v", empty.Count(), empty.Quantile(0.5))
+	}
+}
+
+func TestQuantileSketchBoundedBins(t *testing.T) {
+	// 400 bins of 1% cover a bit more than three orders of magnitude.
+	s := NewQuantileSketch(0.01, 400)
+	for i := -20; i <= 20; i++ {
+		s.Add(math.Pow(10, float64(i)))
+	}
+
+	if n := len(s.positive.bins); n > 400 {
+		t.Errorf("Expected at most 400 bins, got %d", n)
+	}
+
+	// The largest values are kept accurate by collapsing the smallest ones.
+	if got := s.Quantile(1); got != 1e20 {
+		t.Errorf("Quantile(1) = %v, want 1e20", got)
+	}
+	if got, want := s.Quantile(0.95), 1e18; !Float64IsClose(got, want, 0.01, 0) {
+		t.Errorf("Quantile(0.95) = %v, want %v within 1%%", got, want)
+	}
+}
+
+func TestQuantileSketchMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+
+	whole := NewQuantileSketch(0.01, 0)
+	parts := []*QuantileSketch{NewQuantileSketch(0.01, 0), NewQuantileSketch(0.01, 0), NewQuantileSketch(0.005, 0)}
+	for i := 0; i < 3000; i++ {
+		v := rng.ExpFloat64() - 0.2
+		whole.Add(v)
+		parts[i%3].Add(v)
+	}
+
+	merged := NewQuantileSketch(0.01, 0)
+	for _, p := range parts {
+		merged.Merge(p)
+	}
+	merged.Merge(&Summary{})
+
+	if merged.Count() != whole.Count() {
+		t.Fatalf("Count() = %d after merging, want %d", merged.Count(), whole.Count())
+	}
+	for _, q := range []float64{0.01, 0.5, 0.99} {
+		if got, want := merged.Quantile(q), whole.Quantile(q); !Float64IsClose(got, want, 0.03, 0) {
+			t.Errorf("Quantile(%v) = %v after merging, want %v", q, got, want)
+		}
+	}
+}
+
+type latencyProcessor struct {
+	Int64Split
+}
+
+func (p *latencyProcessor) Process(ctx context.Context, batch interface{}) (BatchResult, error) {
+	var s Summary
+	for _, v := range batch.([]int64) {
+		s.Add(float64(v))
+	}
+
+	return &s, nil
+}
+
+func TestSummaryAsBatchResult(t *testing.T) {
+	items := make([]int64, 100)
+	for i := range items {
+		items[i] = int64(i + 1)
+	}
+
+	result, err := BatchProcess(context.Background(), items, 7, 4, &latencyProcessor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := result.(*Summary)
+	if s.Count() != 100 || !Float64IsClose(s.Mean(), 50.5, 1e-12, 0) || s.Min() != 1 || s.Max() != 100 {
+		t.Errorf("Expected 100 values from 1 to 100 with mean 50.5, got %d values from %v to %v with mean %v", s.Count(), s.Min(), s.Max(), s.Mean())
+	}
+}